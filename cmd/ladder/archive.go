@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"github.com/knagayama/ladder"
+	"github.com/knagayama/ladder/spreadsheet"
+)
+
+// An archiveMeta describes how an archived run was made.
+type archiveMeta struct {
+	Version string    `json:"version"`
+	Time    time.Time `json:"time"`
+	Args    []string  `json:"args"`
+}
+
+// toolVersion identifies the build, using the VCS revision when the module
+// has no version of its own.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	revision, modified := "", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision != "" && (version == "" || version == "(devel)") {
+		version = revision
+		if modified {
+			version += "-dirty"
+		}
+	}
+	return version
+}
+
+// archiveRun saves a run into a new timestamped directory under dir: the rows
+// fetched from the spreadsheet, if any, the inputs and results in run.json,
+// and meta.json. It returns the directory.
+func archiveRun(dir string, rows *spreadsheet.Rows, record ladder.RunRecord) (string, error) {
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-round-%d", now.Format("20060102T150405Z"), record.Round)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("unable to create archive: %w", err)
+	}
+	path := filepath.Join(dir, name)
+	for i := 2; ; i++ {
+		err := os.Mkdir(path, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("unable to create archive: %w", err)
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d", name, i))
+	}
+
+	files := map[string]interface{}{
+		"run.json": record,
+		"meta.json": archiveMeta{
+			Version: toolVersion(),
+			Time:    now,
+			Args:    os.Args,
+		},
+	}
+	if rows != nil {
+		files["rows.json"] = rows
+	}
+	for file, v := range files {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("unable to encode %s: %w", file, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(path, file), b, 0644); err != nil {
+			return "", fmt.Errorf("unable to write archive: %w", err)
+		}
+	}
+	fmt.Println("Archived run to", path)
+	return path, nil
+}
+
+// replayCommand resolves an archived run again without the spreadsheet and
+// reports whether it gives the archived matches.
+func replayCommand(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatal("usage: ladder replay <archive dir>")
+	}
+	dir := flags.Arg(0)
+
+	var meta archiveMeta
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "meta.json")); err == nil {
+		if err := json.Unmarshal(b, &meta); err != nil {
+			fatal("load archive", ladder.WrapError(ladder.ErrParse, "parse meta.json", err))
+		}
+		fmt.Println("Archived", meta.Time.Local().Format(time.RFC3339), "by ladder", meta.Version)
+		if version := toolVersion(); meta.Version != version {
+			fmt.Println("Replaying with ladder", version, "instead")
+		}
+	}
+	record, err := loadRun(filepath.Join(dir, "run.json"))
+	if err != nil {
+		fatal("load archive", err)
+	}
+	result, err := ladder.Resolve(record.Teams, record.Prefs, record.Options())
+	if err != nil {
+		fatal("resolve archive", err)
+	}
+
+	printChallenges(result.Round)
+	changes := ladder.DiffChallenges(record.Challenges, result.Round.Chals)
+	if len(changes) == 0 {
+		fmt.Println("Replayed: the archived matches are reproduced exactly.")
+		return
+	}
+	printDiff(result.Round, changes)
+	os.Exit(1)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/knagayama/ladder"
+	"github.com/knagayama/ladder/spreadsheet"
+)
+
+// authFlags select the Google account the spreadsheet is read with.
+type authFlags struct {
+	profile     *string
+	credentials *string
+	token       *string
+}
+
+func addAuthFlags(flags *flag.FlagSet) *authFlags {
+	return &authFlags{
+		profile:     flags.String("profile", "", "Credentials profile, e.g. one per league account (default $"+spreadsheet.EnvProfile+" or "+spreadsheet.DefaultProfile+")"),
+		credentials: flags.String("credentials", "", "OAuth client or service account key file (default from the profile)"),
+		token:       flags.String("token", "", "File the OAuth token is cached in (default from the profile)"),
+	}
+}
+
+func (f *authFlags) config() (spreadsheet.Config, error) {
+	config, err := spreadsheet.ProfileConfig(*f.profile)
+	if err != nil {
+		return config, err
+	}
+	if *f.credentials != "" {
+		config.CredentialsFile = *f.credentials
+	}
+	if *f.token != "" {
+		config.TokenFile = *f.token
+	}
+	return config, nil
+}
+
+// A sheetSource loads rounds from the spreadsheet. The client is created on
+// first use and shared by every later load.
+type sheetSource struct {
+	config spreadsheet.Config
+	// form, if set, is read instead of the prefs sheet.
+	form *spreadsheet.Form
+	// prevChallenged, if set, replaces the previous opponents of the prefs.
+	prevChallenged map[string]string
+	// prevInactivity, if set, replaces the inactivity of the teams.
+	prevInactivity map[string]int
+	// rosters, if set, is the rosters file attached to the teams.
+	rosters string
+
+	mu     sync.Mutex
+	client *spreadsheet.Client
+	// rows are the rows fetched by the last load, for the archive.
+	rows *spreadsheet.Rows
+}
+
+// The spreadsheet holds a single round, so season and round are ignored.
+func (s *sheetSource) load(season string, round int) ([]ladder.Team, []ladder.RawPreference, error) {
+	s.mu.Lock()
+	if s.client == nil {
+		client, err := spreadsheet.NewClient(s.config)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, nil, err
+		}
+		s.client = client
+	}
+	client := s.client
+	s.mu.Unlock()
+
+	var rows spreadsheet.Rows
+	var err error
+	if s.form != nil {
+		rows, err = client.FetchForm(*s.form)
+	} else {
+		rows, err = client.Fetch()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	s.rows = &rows
+	s.mu.Unlock()
+
+	var teams []ladder.Team
+	var prefs []ladder.RawPreference
+	if s.form != nil {
+		teams, prefs, err = rows.ParseForm(*s.form)
+	} else {
+		teams, prefs, err = rows.Parse()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.prevChallenged != nil {
+		for i := range prefs {
+			prefs[i].PrevChallenged = s.prevChallenged[prefs[i].Team]
+		}
+	}
+	if s.prevInactivity != nil {
+		for i := range teams {
+			teams[i].Inactivity = s.prevInactivity[teams[i].Name]
+		}
+	}
+	if s.rosters != "" {
+		if err := loadRosters(s.rosters, teams); err != nil {
+			return nil, nil, err
+		}
+	}
+	return teams, prefs, nil
+}
+
+func (s *sheetSource) lastRows() *spreadsheet.Rows {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rows
+}
+
+// logoutCommand revokes and deletes the cached token of a profile.
+func logoutCommand(args []string) {
+	flags := flag.NewFlagSet("logout", flag.ExitOnError)
+	auth := addAuthFlags(flags)
+	flags.Parse(args)
+	noArgs(flags)
+
+	config, err := auth.config()
+	if err != nil {
+		fatal("select profile", err)
+	}
+	err = spreadsheet.Logout(config)
+	// The token is deleted even if it could not be revoked.
+	if _, statErr := os.Stat(config.TokenFile); os.IsNotExist(statErr) && !errors.Is(err, ladder.ErrValidation) {
+		fmt.Println("Deleted", config.TokenFile)
+	}
+	if err != nil {
+		fatal("log out", err)
+	}
+}
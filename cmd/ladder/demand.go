@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/knagayama/ladder"
+)
+
+// demandCommand resolves the round without printing the trace and shows how
+// contested each named defender is, most contested first.
+func demandCommand(args []string) {
+	flags := flag.NewFlagSet("demand", flag.ExitOnError)
+	input := addInputFlags(flags)
+	seed := flags.Int64("seed", 0, "Seed for every random choice, e.g. the priority of new teams")
+	flags.Parse(args)
+	noArgs(flags)
+
+	teams, prefs, opts, err := input.load()
+	if err != nil {
+		fatal("load round", err)
+	}
+	opts.Seed = *seed
+	result, err := ladder.Resolve(teams, prefs, opts)
+	if err != nil {
+		fatal("resolve round", err)
+	}
+	printDemand(result.Round)
+}
+
+func printDemand(round *ladder.Round) {
+	demands := round.Demand()
+	sort.SliceStable(demands, func(i, j int) bool {
+		return len(demands[i].Contenders) > len(demands[j].Contenders)
+	})
+
+	fmt.Println("==== ラウンド", round.Current, "希望の集中 ====")
+	for _, demand := range demands {
+		contested := ""
+		if demand.Contested() {
+			contested = " (contested)"
+		}
+		var byPick []string
+		for _, count := range demand.Picks {
+			byPick = append(byPick, fmt.Sprint(count))
+		}
+		fmt.Printf("%02d位 %s: %d named for %d slot(s), by pick %s%s\n",
+			demand.Rank, demand.Defender, len(demand.Contenders), demand.Slots, strings.Join(byPick, " / "), contested)
+		for _, contender := range demand.Contenders {
+			name := fmt.Sprintf("%02d位 %s", contender.Rank, contender.Challenger)
+			if contender.New {
+				name = "New! " + contender.Challenger
+			}
+			line := fmt.Sprintf("   #%d %s (pick %d)", contender.Priority, name, contender.Pick)
+			if contender.Reason != "" {
+				line += ": " + contender.Reason
+			}
+			fmt.Println(line)
+		}
+		winners := "nobody"
+		if len(demand.Winners) > 0 {
+			winners = strings.Join(demand.Winners, ", ")
+		}
+		fmt.Println("   Resolved to", winners)
+	}
+}
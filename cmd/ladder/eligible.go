@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/knagayama/ladder"
+)
+
+// eligibleCommand lists the teams a team may pick this round.
+func eligibleCommand(args []string) {
+	flags := flag.NewFlagSet("eligible", flag.ExitOnError)
+	input := addInputFlags(flags)
+	team := flags.String("team", "", "Team name or rank")
+	flags.Parse(args)
+	noArgs(flags)
+
+	teams, prefs, opts, err := input.load()
+	if err != nil {
+		fatal("load round", err)
+	}
+	round, err := ladder.NewRound(teams, prefs, opts)
+	if err != nil {
+		fatal("set up round", err)
+	}
+	challenger, err := round.LookupTeam(*team)
+	if err != nil {
+		fatal("look up team", err)
+	}
+	printEligible(round, challenger)
+}
+
+func printEligible(round *ladder.Round, challenger string) {
+	rank := round.Teams[challenger].Rank
+	fmt.Printf("==== %02d位 %s がチャレンジできるチーム ====\n", rank, challenger)
+	for _, name := range round.Eligible(challenger) {
+		fmt.Printf("%02d位 %s (%s)\n", round.Teams[name].Rank, name, round.Teams[name].Division)
+	}
+	fmt.Printf("==== %02d位 %s がチャレンジできないチーム ====\n", rank, challenger)
+	for _, name := range round.AscOrder {
+		if name == "" || name == challenger || round.Teams[name].Rank > rank {
+			continue
+		}
+		if reason := round.StaticRejection(challenger, name); reason != "" {
+			fmt.Printf("%02d位 %s: %s\n", round.Teams[name].Rank, name, reason)
+		}
+	}
+}
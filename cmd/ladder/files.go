@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/knagayama/ladder"
+	"github.com/knagayama/ladder/spreadsheet"
+)
+
+func loadForbiddenPairs(path string) ([]ladder.ForbiddenPair, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "read forbidden pairs file", err)
+	}
+	var pairs []ladder.ForbiddenPair
+	if err := json.Unmarshal(b, &pairs); err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "parse forbidden pairs file", err)
+	}
+	fmt.Println("Loaded forbidden pairs:", len(pairs))
+	return pairs, nil
+}
+
+func loadInactivityRules(path string) ([]ladder.InactivityRule, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "read inactivity rules file", err)
+	}
+	var rules []ladder.InactivityRule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "parse inactivity rules file", err)
+	}
+	fmt.Println("Loaded inactivity rules:", len(rules))
+	return rules, nil
+}
+
+// loadCooldowns reads the built-in cooldown rules.
+func loadCooldowns(path string) ([]ladder.CooldownRule, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "read cooldowns file", err)
+	}
+	var cooldowns []ladder.Cooldown
+	if err := json.Unmarshal(b, &cooldowns); err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "parse cooldowns file", err)
+	}
+	var rules []ladder.CooldownRule
+	for _, cooldown := range cooldowns {
+		rules = append(rules, cooldown)
+	}
+	fmt.Println("Loaded cooldowns:", len(rules))
+	return rules, nil
+}
+
+// Load rosters keyed by team name and attach them to the teams.
+func loadRosters(path string, teams []ladder.Team) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ladder.WrapError(ladder.ErrParse, "read rosters file", err)
+	}
+	var rosters map[string][]ladder.Player
+	if err := json.Unmarshal(b, &rosters); err != nil {
+		return ladder.WrapError(ladder.ErrParse, "parse rosters file", err)
+	}
+
+	index := make(map[string]int)
+	for i, team := range teams {
+		index[team.Name] = i
+	}
+	var names []string
+	for name := range rosters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		i, ok := index[name]
+		if !ok {
+			fmt.Println("Roster for unknown team", name, "ignored.")
+			continue
+		}
+		teams[i].Roster = rosters[name]
+	}
+	fmt.Println("Loaded rosters:", len(rosters))
+	return nil
+}
+
+// Load the submission tokens keyed by team name.
+func loadTokens(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "read tokens file", err)
+	}
+	var tokens map[string]string
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "parse tokens file", err)
+	}
+	fmt.Println("Loaded tokens:", len(tokens))
+	return tokens, nil
+}
+
+// Load the description of the form responses tab. Missing fields keep the
+// values of spreadsheet.DefaultForm.
+func loadForm(path string) (spreadsheet.Form, error) {
+	form := spreadsheet.DefaultForm()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return form, ladder.WrapError(ladder.ErrParse, "read form file", err)
+	}
+	if err := json.Unmarshal(b, &form); err != nil {
+		return form, ladder.WrapError(ladder.ErrParse, "parse form file", err)
+	}
+	return form, nil
+}
+
+// Read override or patch commands, one per line. Lines starting with # are
+// comments.
+func loadCommands(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "read "+path, err)
+	}
+	defer f.Close()
+
+	var commands []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commands = append(commands, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "read "+path, err)
+	}
+	return commands, nil
+}
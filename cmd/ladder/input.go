@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/knagayama/ladder"
+	"github.com/knagayama/ladder/server"
+)
+
+// inputFlags are the flags of every command that loads a round.
+type inputFlags struct {
+	round              *int
+	forbidden          *string
+	rosters            *string
+	avoidSharedPlayers *bool
+	prefsDir           *string
+	form               *string
+	deadline           *string
+	late               *string
+	maxPicks           *int
+	defaultAccept      *bool
+	defaultChallenge   *string
+	prevRun            *string
+	cooldowns          *string
+	history            *string
+	auth               *authFlags
+
+	// source is the spreadsheet source of the last config.
+	source *sheetSource
+}
+
+func addInputFlags(flags *flag.FlagSet) *inputFlags {
+	return &inputFlags{
+		round:              flags.Int("round", 0, "Current round"),
+		forbidden:          flags.String("forbidden", "", "JSON file of team pairs that must never play each other"),
+		rosters:            flags.String("rosters", "", "JSON file of team rosters"),
+		avoidSharedPlayers: flags.Bool("avoid-shared-players", false, "Never schedule two teams whose rosters share a player, against each other or in separate matches"),
+		prefsDir:           flags.String("prefs-dir", "", "Directory of preferences submitted on the submission page"),
+		form:               flags.String("form", "", "JSON description of the form responses tab to read instead of the prefs sheet"),
+		deadline:           flags.String("deadline", "", "Submission deadline, e.g. 2024-05-01T21:00:00+09:00 (default from --form)"),
+		late:               flags.String("late", "reject", "What to do with late preferences: reject, lowest-priority or accept-only"),
+		maxPicks:           flags.Int("max-picks", 0, "Ignore the picks of every team after this many; 0 for no limit (the submission page offers 3)"),
+		defaultAccept:      flags.Bool("default-accept", false, "Make the teams that submitted nothing accept challenges"),
+		defaultChallenge:   flags.String("default-challenge", "", "Make the teams that submitted nothing challenge with this last resort: min-rank, max-rank or any"),
+		prevRun:            flags.String("prev-run", "", "Saved run of the previous round, to take the previous opponents and inactivity from"),
+		cooldowns:          flags.String("cooldowns", "", "JSON file of the cooldown rules checked against the history"),
+		history:            flags.String("history", "archive", "Directory of archived or saved runs of the season"),
+		auth:               addAuthFlags(flags),
+	}
+}
+
+// config returns the server configuration reading from the spreadsheet and
+// the preferences directory, with the options every resolution of a round
+// shares.
+func (f *inputFlags) config() (server.Config, error) {
+	auth, err := f.auth.config()
+	if err != nil {
+		return server.Config{}, err
+	}
+	f.source = &sheetSource{config: auth, rosters: *f.rosters}
+	if *f.form != "" {
+		form, err := loadForm(*f.form)
+		if err != nil {
+			return server.Config{}, err
+		}
+		form.Log = os.Stdout
+		f.source.form = &form
+		if *f.prevRun == "" && *f.round > 1 {
+			log.Println("Warning: the form does not ask for previous opponents; without --prev-run, teams may challenge their previous opponent again.")
+		}
+	}
+	if *f.prevRun != "" {
+		record, err := loadRun(*f.prevRun)
+		if err != nil {
+			return server.Config{}, err
+		}
+		f.source.prevChallenged = record.PrevChallenged()
+		f.source.prevInactivity = record.Inactivity
+	}
+	config := server.Config{Source: f.source.load, MaxPicks: *f.maxPicks}
+	if config.Options, err = f.options(); err != nil {
+		return config, err
+	}
+	if *f.prefsDir != "" {
+		store, err := server.NewFileStore(*f.prefsDir)
+		if err != nil {
+			return config, err
+		}
+		config.Store = store
+	}
+	return config, nil
+}
+
+// options returns the options set by the flags. config must have set up the
+// source first.
+func (f *inputFlags) options() (ladder.Options, error) {
+	opts := ladder.Options{
+		Round:              *f.round,
+		AvoidSharedPlayers: *f.avoidSharedPlayers,
+		MaxPicks:           *f.maxPicks,
+		PrevOpponents:      f.source.prevChallenged,
+	}
+	var err error
+	if *f.forbidden != "" {
+		if opts.Forbidden, err = loadForbiddenPairs(*f.forbidden); err != nil {
+			return opts, err
+		}
+	}
+	if opts.LatePolicy, err = ladder.ParseLatePolicy(*f.late); err != nil {
+		return opts, err
+	}
+	// The form picks each team's response with the same deadline as the
+	// late policy.
+	if *f.deadline != "" {
+		if opts.Deadline, err = time.Parse(time.RFC3339, *f.deadline); err != nil {
+			return opts, ladder.WrapError(ladder.ErrParse, "parse deadline", err)
+		}
+		if f.source.form != nil {
+			f.source.form.Deadline = opts.Deadline
+		}
+	} else if f.source.form != nil {
+		opts.Deadline = f.source.form.Deadline
+	}
+	if opts.DefaultPreference, err = f.defaultPreference(); err != nil {
+		return opts, err
+	}
+	if *f.cooldowns != "" {
+		if opts.Cooldowns, err = loadCooldowns(*f.cooldowns); err != nil {
+			return opts, err
+		}
+		records, err := loadHistory(*f.history)
+		if err != nil {
+			return opts, err
+		}
+		opts.History = ladder.NewHistory(records)
+	}
+	return opts, nil
+}
+
+// load reads the teams and preferences of the round, with the rosters
+// attached, and the options every resolution of it shares.
+func (f *inputFlags) load() ([]ladder.Team, []ladder.RawPreference, ladder.Options, error) {
+	config, err := f.config()
+	if err != nil {
+		return nil, nil, ladder.Options{}, err
+	}
+	teams, prefs, err := config.LoadRound("", *f.round)
+	if err != nil {
+		return nil, nil, config.Options, err
+	}
+	return teams, prefs, config.Options, nil
+}
+
+// defaultPreference returns the preference given to the teams that submitted
+// nothing, or nil if no default flag is set.
+func (f *inputFlags) defaultPreference() (*ladder.ProcessedPreference, error) {
+	if !*f.defaultAccept && *f.defaultChallenge == "" {
+		return nil, nil
+	}
+	pref := ladder.ProcessedPreference{Accept: *f.defaultAccept}
+	if *f.defaultChallenge != "" {
+		lastResort, err := ladder.ParseLastResort(*f.defaultChallenge)
+		if err != nil {
+			return nil, err
+		}
+		pref.Challenge = lastResort != ladder.None
+		pref.LastResortPref = lastResort
+	}
+	return &pref, nil
+}
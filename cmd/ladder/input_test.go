@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/knagayama/ladder/spreadsheet"
+)
+
+func TestOptionsDeadline(t *testing.T) {
+	formDeadline := time.Date(2024, 5, 1, 21, 0, 0, 0, time.UTC)
+	flagDeadline := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		args []string
+		want time.Time
+	}{
+		{"from the form", nil, formDeadline},
+		{"from the flag", []string{"--deadline", flagDeadline.Format(time.RFC3339)}, flagDeadline},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			f := addInputFlags(flags)
+			if err := flags.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+			f.source = &sheetSource{form: &spreadsheet.Form{Deadline: formDeadline}}
+			opts, err := f.options()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !opts.Deadline.Equal(test.want) {
+				t.Errorf("late policy deadline = %v, want %v", opts.Deadline, test.want)
+			}
+			if !f.source.form.Deadline.Equal(test.want) {
+				t.Errorf("form deadline = %v, want %v", f.source.form.Deadline, test.want)
+			}
+		})
+	}
+}
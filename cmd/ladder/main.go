@@ -0,0 +1,149 @@
+// Command ladder resolves a round of challenges from the challenge form
+// spreadsheet and prints the resulting matches.
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/knagayama/ladder"
+	"github.com/knagayama/ladder/server"
+)
+
+// Exit with a message that tells the TO what to do about err.
+func fatal(action string, err error) {
+	switch {
+	case errors.Is(err, ladder.ErrAuth):
+		log.Fatalf("Unable to %s: %v\nCheck the credentials file, or run `ladder logout` to sign in again.", action, err)
+	case errors.Is(err, ladder.ErrNetwork):
+		log.Fatalf("Unable to %s: %v\nThe spreadsheet could not be reached; try again later.", action, err)
+	case errors.Is(err, ladder.ErrParse):
+		log.Fatalf("Unable to %s: %v\nFix the malformed input and try again.", action, err)
+	default:
+		log.Fatalf("Unable to %s: %v", action, err)
+	}
+}
+
+// Exit if a command got positional arguments it does not take, such as the
+// value of a boolean flag given as "--manual true".
+func noArgs(flags *flag.FlagSet) {
+	if flags.NArg() > 0 {
+		log.Fatalf("Unexpected argument %q; boolean flags take no separate value, e.g. --manual or --manual=false.", flags.Arg(0))
+	}
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "eligible":
+			eligibleCommand(os.Args[2:])
+			return
+		case "demand":
+			demandCommand(os.Args[2:])
+			return
+		case "simulate":
+			simulateCommand(os.Args[2:])
+			return
+		case "withdraw":
+			withdrawCommand(os.Args[2:])
+			return
+		case "logout":
+			logoutCommand(os.Args[2:])
+			return
+		case "replay":
+			replayCommand(os.Args[2:])
+			return
+		case "matrix":
+			matrixCommand(os.Args[2:])
+			return
+		case "result":
+			resultCommand(os.Args[2:])
+			return
+		case "penalties":
+			penaltiesCommand(os.Args[2:])
+			return
+		}
+	}
+
+	input := addInputFlags(flag.CommandLine)
+	manualAssignLeftover := flag.Bool("manual", false, "Manually assign leftovers")
+	overridesFile := flag.String("overrides", "", "File of override commands to apply after resolution")
+	interactiveOverride := flag.Bool("override", false, "Interactively override matches after resolution")
+	seed := flag.Int64("seed", 0, "Seed for every random choice, e.g. the priority of new teams")
+	saveFile := flag.String("save", "", "Save the inputs and results of this run to a file")
+	verifyFile := flag.String("verify", "", "Resolve a saved run again and check the results are identical")
+	serveAddr := flag.String("serve", "", "Serve the HTTP API on this address instead of resolving once, e.g. :8080")
+	archiveDir := flag.String("archive", "archive", "Archive the fetched inputs and results of every run under this directory; empty to disable")
+	tokensFile := flag.String("tokens", "", "JSON file of the secret each team must present to submit preferences")
+	openSubmissions := flag.Bool("open-submissions", false, "Let anyone submit preferences for any team without --tokens")
+	flag.Parse()
+	noArgs(flag.CommandLine)
+
+	if *serveAddr != "" {
+		config, err := input.config()
+		if err != nil {
+			fatal("configure server", err)
+		}
+		if *tokensFile != "" {
+			if config.Tokens, err = loadTokens(*tokensFile); err != nil {
+				fatal("load tokens", err)
+			}
+		} else if config.Store != nil && !*openSubmissions {
+			fatal("configure server", ladder.Errorf(ladder.ErrValidation, "serve submissions", "--prefs-dir needs --tokens, or --open-submissions to let anyone submit for any team"))
+		}
+		config.OpenSubmissions = *openSubmissions
+		log.Println("Serving the ladder API on", *serveAddr)
+		log.Fatal(http.ListenAndServe(*serveAddr, server.New(config)))
+	}
+
+	if *verifyFile != "" {
+		identical, err := verifyRun(*verifyFile)
+		if err != nil {
+			fatal("verify run", err)
+		}
+		if !identical {
+			os.Exit(1)
+		}
+		return
+	}
+
+	teams, prefs, opts, err := input.load()
+	if err != nil {
+		fatal("load round", err)
+	}
+	opts.Seed = *seed
+	opts.Log = os.Stdout
+	if *manualAssignLeftover {
+		opts.ManualAssign = manualAssign
+	}
+	if *overridesFile != "" {
+		if opts.Overrides, err = loadCommands(*overridesFile); err != nil {
+			fatal("load overrides", err)
+		}
+	}
+
+	result, err := ladder.Resolve(teams, prefs, opts)
+	if err != nil {
+		fatal("resolve round", err)
+	}
+	round := result.Round
+
+	printChallenges(round)
+	if *interactiveOverride {
+		overrideSession(round)
+		printChallenges(round)
+	}
+	if *saveFile != "" {
+		if err := saveRun(*saveFile, round.Record()); err != nil {
+			fatal("save run", err)
+		}
+	}
+	if *archiveDir != "" {
+		if _, err := archiveRun(*archiveDir, input.source.lastRows(), round.Record()); err != nil {
+			fatal("archive run", err)
+		}
+	}
+}
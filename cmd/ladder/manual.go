@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/knagayama/ladder"
+)
+
+// Shared by every interactive prompt so that buffered input is not lost.
+var stdin = bufio.NewScanner(os.Stdin)
+
+const manualHelp = "<rank> or <team>: assign, s: skip, u: undo, a: auto-assign the rest, ?: help"
+
+// A manualPick records one decision so that it can be undone.
+type manualPick struct {
+	index      int
+	challenger string
+	challenge  *ladder.Challenge
+}
+
+// Walk the TO through every deferred challenger, showing the ladder with the
+// teams it can take highlighted.
+func manualAssign(round *ladder.Round, deferredTeams []string) {
+	var picks []manualPick
+	message := manualHelp
+
+	// Keep the decisions that stand so that the run can be replayed.
+	defer func() {
+		round.ManualPicks = nil
+		for _, pick := range picks {
+			var defender string
+			if pick.challenge != nil {
+				defender = pick.challenge.Defender
+			}
+			round.ManualPicks = append(round.ManualPicks, ladder.ManualPick{Challenger: pick.challenger, Defender: defender})
+		}
+	}()
+
+	for i := 0; i < len(deferredTeams); {
+		challenger := deferredTeams[i]
+		printLadder(round, challenger, i, len(deferredTeams), picks, message)
+		message = ""
+
+		if !stdin.Scan() {
+			fmt.Println("End of input, auto-assigning the remaining teams.")
+			round.AutoAssign(deferredTeams[i:])
+			return
+		}
+		input := strings.TrimSpace(stdin.Text())
+
+		switch strings.ToLower(input) {
+		case "":
+			continue
+		case "?", "h", "help":
+			message = manualHelp
+		case "s", "skip":
+			picks = append(picks, manualPick{index: i, challenger: challenger})
+			i++
+		case "u", "undo":
+			if len(picks) == 0 {
+				message = "Nothing to undo."
+				continue
+			}
+			last := picks[len(picks)-1]
+			picks = picks[:len(picks)-1]
+			if last.challenge != nil {
+				round.Unassign(last.challenger)
+			}
+			i = last.index
+			message = fmt.Sprint("Undid assignment for ", last.challenger, ".")
+		case "a", "auto":
+			round.AutoAssign(deferredTeams[i:])
+			return
+		default:
+			defender, err := round.LookupTeam(input)
+			if err != nil {
+				message = err.Error()
+				continue
+			}
+			challenge, err := round.Assign(challenger, defender)
+			if err != nil {
+				message = err.Error()
+				continue
+			}
+			picks = append(picks, manualPick{index: i, challenger: challenger, challenge: challenge})
+			i++
+		}
+	}
+}
+
+func printLadder(round *ladder.Round, challenger string, index int, total int, picks []manualPick, message string) {
+	teams := round.Teams
+
+	// Clear the screen and move the cursor to the top.
+	fmt.Print("\033[H\033[2J")
+	fmt.Println("==== ラウンド", round.Current, "手動割り当て ====")
+	for _, team := range round.AscOrder {
+		if team == "" {
+			continue
+		}
+		info := teams[team]
+		rank := fmt.Sprintf("%02d位", info.Rank)
+		if info.New {
+			rank = "New "
+		}
+		status := ""
+		switch {
+		case info.Rank == 1 && info.Taken && !info.TakenTwo:
+			status = "1/2"
+		case round.CheckTaken(team):
+			status = "taken"
+		}
+		line := fmt.Sprintf("%s %-3s %-24s %s", rank, info.Division, team, status)
+		if team == challenger {
+			fmt.Println("\033[1m>", line, "\033[0m")
+		} else if round.MatchRejection(challenger, team, true) == "" {
+			fmt.Println("\033[32m*", line, "\033[0m")
+		} else {
+			fmt.Println(" ", line)
+		}
+	}
+
+	if len(picks) > 0 {
+		fmt.Println("---- 割り当て済み ----")
+		for _, pick := range picks {
+			if pick.challenge != nil {
+				fmt.Println(pick.challenger, "vs", pick.challenge.Defender)
+			} else {
+				fmt.Println(pick.challenger, "skipped")
+			}
+		}
+	}
+
+	fmt.Println("----")
+	fmt.Printf("[%d/%d] Choose an opponent for %s @ %d\n", index+1, total, challenger, teams[challenger].Rank)
+	if message != "" {
+		fmt.Println(message)
+	}
+	fmt.Print("> ")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/knagayama/ladder"
+)
+
+// matrixCommand exports the eligibility matrix of the round as CSV, SVG or
+// both. Without --csv or --svg, the CSV is written to stdout.
+func matrixCommand(args []string) {
+	flags := flag.NewFlagSet("matrix", flag.ExitOnError)
+	input := addInputFlags(flags)
+	csvFile := flags.String("csv", "", "Write the matrix as CSV to this file")
+	svgFile := flags.String("svg", "", "Write the matrix as a heatmap SVG to this file")
+	flags.Parse(args)
+	noArgs(flags)
+
+	teams, prefs, opts, err := input.load()
+	if err != nil {
+		fatal("load round", err)
+	}
+	round, err := ladder.NewRound(teams, prefs, opts)
+	if err != nil {
+		fatal("set up round", err)
+	}
+	matrix := round.EligibilityMatrix()
+
+	if *csvFile == "" && *svgFile == "" {
+		if err := matrix.WriteCSV(os.Stdout); err != nil {
+			fatal("write matrix", err)
+		}
+		return
+	}
+	if *csvFile != "" {
+		if err := writeFile(*csvFile, matrix.WriteCSV); err != nil {
+			fatal("write matrix", err)
+		}
+	}
+	if *svgFile != "" {
+		if err := writeFile(*svgFile, matrix.WriteSVG); err != nil {
+			fatal("write matrix", err)
+		}
+	}
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %w", path, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	fmt.Println("Wrote", path)
+	return nil
+}
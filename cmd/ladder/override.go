@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/knagayama/ladder"
+)
+
+const overrideHelp = "pin <challenger> <defender>, swap <challenger> <challenger>, remove <challenger>, done"
+
+func overrideSession(round *ladder.Round) {
+	fmt.Println("==== ラウンド", round.Current, "修正 ====")
+	fmt.Println(overrideHelp)
+	for {
+		fmt.Print("override> ")
+		if !stdin.Scan() {
+			return
+		}
+		line := strings.TrimSpace(stdin.Text())
+		switch line {
+		case "":
+			continue
+		case "done", "q":
+			return
+		case "?", "help":
+			fmt.Println(overrideHelp)
+			continue
+		}
+		if err := round.ApplyOverride(line); err != nil {
+			fmt.Println("Override rejected:", err)
+			continue
+		}
+		printChallenges(round)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/knagayama/ladder"
+	"github.com/knagayama/ladder/spreadsheet"
+)
+
+// penaltiesCommand applies the inactivity rules to the archived rounds and
+// prints the teams table of the next round, with an audit line for every
+// penalty.
+func penaltiesCommand(args []string) {
+	flags := flag.NewFlagSet("penalties", flag.ExitOnError)
+	input := addInputFlags(flags)
+	rulesFile := flags.String("rules", "", "JSON file of the inactivity rules")
+	outFile := flags.String("out", "", "Write the teams table as CSV to this file instead of printing it")
+	flags.Parse(args)
+	noArgs(flags)
+
+	if *rulesFile == "" {
+		log.Fatal("usage: ladder penalties --rules <file> [--history <dir>] [--out <file>]")
+	}
+	rules, err := loadInactivityRules(*rulesFile)
+	if err != nil {
+		fatal("load inactivity rules", err)
+	}
+	history, err := loadHistory(*input.history)
+	if err != nil {
+		fatal("load history", err)
+	}
+	teams, _, _, err := input.load()
+	if err != nil {
+		fatal("load round", err)
+	}
+
+	next, penalties, err := ladder.ApplyInactivityRules(teams, history, rules)
+	if err != nil {
+		fatal("apply inactivity rules", err)
+	}
+	fmt.Println("==== 次ラウンド ペナルティ ====")
+	if len(penalties) == 0 {
+		fmt.Println("No penalties.")
+	}
+	for _, penalty := range penalties {
+		fmt.Println(penalty)
+	}
+
+	write := func(w io.Writer) error {
+		out := csv.NewWriter(w)
+		out.Write([]string{"prev_rank", "rank", "new", "division", "team", "groups", "force_accept"})
+		for _, row := range spreadsheet.TeamRows(next) {
+			var record []string
+			for _, cell := range row {
+				record = append(record, fmt.Sprint(cell))
+			}
+			out.Write(record)
+		}
+		out.Flush()
+		return out.Error()
+	}
+	if *outFile != "" {
+		if err := writeFile(*outFile, write); err != nil {
+			fatal("write teams table", err)
+		}
+		return
+	}
+	fmt.Println("==== 次ラウンド チーム表csv ====")
+	if err := write(os.Stdout); err != nil {
+		fatal("write teams table", err)
+	}
+}
+
+// loadHistory reads every run.json under dir, or dir itself if it is a saved
+// run, in path order so that the latest archive of a round comes last.
+func loadHistory(dir string) ([]ladder.RunRecord, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "read history", err)
+	}
+	if !info.IsDir() {
+		record, err := loadRun(dir)
+		if err != nil {
+			return nil, err
+		}
+		return []ladder.RunRecord{record}, nil
+	}
+
+	var paths []string
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && info.Name() == "run.json" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "read history", err)
+	}
+	sort.Strings(paths)
+
+	var history []ladder.RunRecord
+	for _, path := range paths {
+		record, err := loadRun(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		history = append(history, record)
+	}
+	fmt.Println("Loaded runs:", len(history))
+	return history, nil
+}
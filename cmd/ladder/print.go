@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/knagayama/ladder"
+)
+
+func printChallenges(round *ladder.Round) {
+	fmt.Println("==== ラウンド", round.Current, "全試合 ====")
+	for _, challenger := range round.AscOrder {
+		challenge := round.Chals[challenger]
+		if challenge != nil && challenge.ValidMatch {
+			if round.Teams[challenger].New {
+				fmt.Printf("[%d-%02d] New! %s vs %02d位 %s\n", challenge.Round, challenge.MatchCode, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
+			} else {
+				fmt.Printf("[%d-%02d] %02d位 %s vs %02d位 %s\n", challenge.Round, challenge.MatchCode, challenge.ChallengerRank, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
+			}
+		}
+	}
+	fmt.Println("==== ラウンド", round.Current, "全試合csv ====")
+	fmt.Println("id,挑戦側rank,挑戦側チーム名,防衛側rank,防衛側チーム名")
+	for _, challenger := range round.AscOrder {
+		challenge := round.Chals[challenger]
+		if challenge != nil && challenge.ValidMatch {
+			if round.Teams[challenger].New {
+				fmt.Printf("[%d-%02d],New,%s,%02d,%s\n", challenge.Round, challenge.MatchCode, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
+			} else {
+				fmt.Printf("[%d-%02d],%02d位,%s,%02d位,%s\n", challenge.Round, challenge.MatchCode, challenge.ChallengerRank, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
+			}
+		}
+	}
+	printRejections(round)
+	printLateEntries(round)
+	printDefaulted(round)
+	printRosterConflicts(round)
+}
+
+func printLateEntries(round *ladder.Round) {
+	if len(round.Late) == 0 {
+		return
+	}
+	fmt.Println("==== ラウンド", round.Current, "締切後の提出 ====")
+	fmt.Println("Deadline:", round.Deadline.Format("2006/01/02 15:04:05 MST"))
+	for _, entry := range round.Late {
+		var effect string
+		switch entry.Policy {
+		case ladder.LateReject:
+			effect = "ignored"
+		case ladder.LateLowestPriority:
+			effect = "challenges last"
+		case ladder.LateAcceptOnly:
+			effect = "only accepts challenges"
+		}
+		fmt.Println(entry.Team, "submitted at", entry.SubmittedAt.In(round.Deadline.Location()).Format("2006/01/02 15:04:05"), "and", effect)
+	}
+}
+
+func printDefaulted(round *ladder.Round) {
+	if len(round.Defaulted) == 0 {
+		return
+	}
+	inactivity := round.Inactivity()
+	fmt.Println("==== ラウンド", round.Current, "未提出 ====")
+	if pref := round.DefaultPreference; pref != nil {
+		fmt.Println("Default: accept", pref.Accept, "challenge", pref.Challenge, "last resort", pref.LastResortPref)
+	} else {
+		fmt.Println("Default: sit out")
+	}
+	for _, team := range round.Defaulted {
+		fmt.Printf("%s (inactive for %d round(s))\n", team, inactivity[team])
+	}
+}
+
+func printRejections(round *ladder.Round) {
+	fmt.Println("==== ラウンド", round.Current, "不成立の理由 ====")
+	for _, challenger := range round.AscOrder {
+		rejections := round.Rejections[challenger]
+		if challenger == "" || len(rejections) == 0 {
+			continue
+		}
+		if challenge := round.Chals[challenger]; challenge != nil && challenge.ValidMatch {
+			fmt.Println(challenger, "(matched with", challenge.Defender+")")
+		} else {
+			fmt.Println(challenger, "(no match)")
+		}
+		for _, rejection := range rejections {
+			fmt.Println("  ", rejection.Defender+":", rejection.Reason)
+		}
+	}
+}
+
+func printRosterConflicts(round *ladder.Round) {
+	conflicts := round.RosterConflicts()
+	if len(conflicts) == 0 {
+		return
+	}
+	fmt.Println("==== ラウンド", round.Current, "選手の重複 ====")
+	for _, conflict := range conflicts {
+		var players []string
+		for _, player := range conflict.Players {
+			players = append(players, player.String())
+		}
+		where := "in separate matches"
+		if conflict.SameMatch {
+			where = "against each other"
+		}
+		fmt.Println(conflict.Team, "and", conflict.Other, "play", where, "sharing", strings.Join(players, ", "))
+	}
+}
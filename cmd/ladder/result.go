@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// resultCommand reports the winner of a match in a saved or archived run, for
+// the cooldown rules of later rounds.
+func resultCommand(args []string) {
+	flags := flag.NewFlagSet("result", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() != 3 {
+		log.Fatal("usage: ladder result <run file or archive dir> <challenger> <winner>")
+	}
+	path := flags.Arg(0)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "run.json")
+	}
+
+	record, err := loadRun(path)
+	if err != nil {
+		fatal("load run", err)
+	}
+	if err := record.SetResult(flags.Arg(1), flags.Arg(2)); err != nil {
+		fatal("set result", err)
+	}
+	if err := saveRun(path, record); err != nil {
+		fatal("save run", err)
+	}
+	fmt.Println("Round", record.Round, flags.Arg(1), "vs", record.Challenges[flags.Arg(1)].Defender+": won by", flags.Arg(2))
+}
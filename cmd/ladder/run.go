@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/knagayama/ladder"
+)
+
+func saveRun(path string, record ladder.RunRecord) error {
+	b, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode run: %w", err)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("unable to save run: %w", err)
+	}
+	fmt.Println("Saved run to", path)
+	return nil
+}
+
+func loadRun(path string) (ladder.RunRecord, error) {
+	var record ladder.RunRecord
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return record, ladder.WrapError(ladder.ErrParse, "read run file", err)
+	}
+	if err := json.Unmarshal(b, &record); err != nil {
+		return record, ladder.WrapError(ladder.ErrParse, "parse run file", err)
+	}
+	return record, nil
+}
+
+// Resolve a saved run again and report whether it gives identical challenges.
+func verifyRun(path string) (bool, error) {
+	record, err := loadRun(path)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := ladder.Resolve(record.Teams, record.Prefs, record.Options())
+	if err != nil {
+		return false, err
+	}
+	chals := result.Round.Chals
+
+	var challengers []string
+	seen := make(map[string]bool)
+	for _, c := range []map[string]*ladder.Challenge{record.Challenges, chals} {
+		for challenger := range c {
+			if !seen[challenger] {
+				seen[challenger] = true
+				challengers = append(challengers, challenger)
+			}
+		}
+	}
+	sort.Strings(challengers)
+
+	identical := true
+	for _, challenger := range challengers {
+		saved, resolved := record.Challenges[challenger], chals[challenger]
+		switch {
+		case saved == nil:
+			fmt.Printf("%s: not in the saved run, resolved to %+v\n", challenger, *resolved)
+		case resolved == nil:
+			fmt.Printf("%s: saved as %+v, not resolved again\n", challenger, *saved)
+		case *saved != *resolved:
+			fmt.Printf("%s: saved as %+v, resolved to %+v\n", challenger, *saved, *resolved)
+		default:
+			continue
+		}
+		identical = false
+	}
+
+	if identical {
+		fmt.Println("Verified: resolving", path, "again gives identical challenges.")
+	} else {
+		fmt.Println("Verification failed for", path)
+	}
+	return identical, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/knagayama/ladder"
+)
+
+func TestVerifyRun(t *testing.T) {
+	teams := []ladder.Team{
+		{Rank: 1, Name: "A", Division: "A"},
+		{Rank: 2, Name: "B", Division: "A"},
+		{Rank: 3, Name: "C", Division: "A"},
+	}
+	prefs := []ladder.RawPreference{
+		{Team: "A", Accept: ladder.AnswerAccept, Challenge: ladder.AnswerNoChallenge},
+		{Team: "B", Accept: ladder.AnswerAccept, Challenge: ladder.AnswerChallenge, Picks: []string{"A"}},
+		{Team: "C", Accept: ladder.AnswerAccept, Challenge: ladder.AnswerChallenge, Picks: []string{"B"}},
+	}
+	result, err := ladder.Resolve(teams, prefs, ladder.Options{Round: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := result.Round.Record()
+
+	tests := []struct {
+		name   string
+		tamper func(record *ladder.RunRecord)
+		want   bool
+	}{
+		{"saved", func(record *ladder.RunRecord) {}, true},
+		{"other defender", func(record *ladder.RunRecord) {
+			challenge := *record.Challenges["C"]
+			challenge.Defender = "A"
+			record.Challenges["C"] = &challenge
+		}, false},
+		{"missing match", func(record *ladder.RunRecord) {
+			delete(record.Challenges, "B")
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			saved := record
+			saved.Challenges = make(map[string]*ladder.Challenge)
+			for challenger, challenge := range record.Challenges {
+				saved.Challenges[challenger] = challenge
+			}
+			test.tamper(&saved)
+			path := filepath.Join(t.TempDir(), "run.json")
+			if err := saveRun(path, saved); err != nil {
+				t.Fatal(err)
+			}
+			identical, err := verifyRun(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identical != test.want {
+				t.Errorf("verifyRun() = %v, want %v", identical, test.want)
+			}
+		})
+	}
+}
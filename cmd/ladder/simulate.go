@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/knagayama/ladder"
+)
+
+// simulateCommand resolves the round as it stands and again with the patch
+// commands applied, and prints how the matches differ.
+func simulateCommand(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	input := addInputFlags(flags)
+	seed := flags.Int64("seed", 0, "Seed for every random choice, e.g. the priority of new teams")
+	overridesFile := flags.String("overrides", "", "File of override commands to apply after both resolutions")
+	patchFile := flags.String("patch", "", "File of patch commands, one per line")
+	flags.Parse(args)
+
+	teams, prefs, opts, err := input.load()
+	if err != nil {
+		fatal("load round", err)
+	}
+	opts.Seed = *seed
+	if *overridesFile != "" {
+		if opts.Overrides, err = loadCommands(*overridesFile); err != nil {
+			fatal("load overrides", err)
+		}
+	}
+	var patch []string
+	if *patchFile != "" {
+		if patch, err = loadCommands(*patchFile); err != nil {
+			fatal("load patch", err)
+		}
+	}
+	// Commands may also be given as arguments, e.g. "withdraw Team A".
+	patch = append(patch, flags.Args()...)
+
+	base, err := ladder.Resolve(teams, prefs, opts)
+	if err != nil {
+		fatal("resolve round", err)
+	}
+	patched, err := base.Round.PatchPreferences(patch)
+	if err != nil {
+		fatal("apply patch", err)
+	}
+	result, err := ladder.Resolve(teams, patched, opts)
+	if err != nil {
+		fatal("resolve patched round", err)
+	}
+
+	printDiff(base.Round, ladder.DiffChallenges(base.Round.Chals, result.Round.Chals))
+}
+
+func printDiff(round *ladder.Round, changes []ladder.ChallengeChange) {
+	fmt.Println("==== ラウンド", round.Current, "変更される試合 ====")
+	if len(changes) == 0 {
+		fmt.Println("No matches change.")
+		return
+	}
+	for _, change := range changes {
+		switch change.Kind {
+		case ladder.ChangeAdded:
+			fmt.Println("+", formatChallenge(round, change.After))
+		case ladder.ChangeRemoved:
+			fmt.Println("-", formatChallenge(round, change.Before))
+		case ladder.ChangeDefender:
+			fmt.Println("~", formatChallenge(round, change.Before), "->", formatChallenge(round, change.After))
+		case ladder.ChangeMatchCode:
+			fmt.Printf("~ %s: [%d-%02d] -> [%d-%02d]\n", change.Challenger,
+				change.Before.Round, change.Before.MatchCode, change.After.Round, change.After.MatchCode)
+		}
+	}
+}
+
+func formatChallenge(round *ladder.Round, challenge *ladder.Challenge) string {
+	if round.Teams[challenge.Challenger] != nil && round.Teams[challenge.Challenger].New {
+		return fmt.Sprintf("[%d-%02d] New! %s vs %02d位 %s", challenge.Round, challenge.MatchCode, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
+	}
+	return fmt.Sprintf("[%d-%02d] %02d位 %s vs %02d位 %s", challenge.Round, challenge.MatchCode, challenge.ChallengerRank, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
+}
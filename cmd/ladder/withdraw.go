@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/knagayama/ladder"
+)
+
+// withdrawCommand takes teams out of a published run without reshuffling the
+// matches they are not part of.
+func withdrawCommand(args []string) {
+	flags := flag.NewFlagSet("withdraw", flag.ExitOnError)
+	runFile := flags.String("run", "", "Saved run of the published matches")
+	refill := flags.Bool("refill", false, "Let challengers without a match take the freed defenders")
+	saveFile := flags.String("save", "", "Save the updated run to a file")
+	flags.Parse(args)
+	if *runFile == "" || flags.NArg() == 0 {
+		log.Fatal("usage: ladder withdraw --run <file> [--refill] [--save <file>] <team>...")
+	}
+
+	record, err := loadRun(*runFile)
+	if err != nil {
+		fatal("load run", err)
+	}
+	result, err := ladder.Resolve(record.Teams, record.Prefs, record.Options())
+	if err != nil {
+		fatal("resolve run", err)
+	}
+	round := result.Round
+	if changes := ladder.DiffChallenges(record.Challenges, round.Chals); len(changes) > 0 {
+		log.Fatalf("%s no longer resolves to the saved matches; check it with --verify", *runFile)
+	}
+	if *refill != round.Refill {
+		for _, line := range round.Overrides {
+			if strings.HasPrefix(strings.ToLower(line), "withdraw") {
+				log.Fatalf("%s already has withdrawals resolved with --refill=%v", *runFile, round.Refill)
+			}
+		}
+		round.Refill = *refill
+	}
+
+	round.Log = os.Stdout
+	for _, team := range flags.Args() {
+		line := fmt.Sprintf("withdraw \"%s\"", strings.ReplaceAll(team, "\"", "\"\""))
+		if err := round.ApplyOverride(line); err != nil {
+			fatal("withdraw "+team, err)
+		}
+	}
+
+	printDiff(round, ladder.DiffChallenges(record.Challenges, round.Chals))
+	printChallenges(round)
+	if *saveFile != "" {
+		if err := saveRun(*saveFile, round.Record()); err != nil {
+			fatal("save run", err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ladder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ForbiddenPair names two teams that must never be scheduled against each
+// other, e.g. because they share substitutes or are under a dispute.
+type ForbiddenPair struct {
+	Team     string `json:"team"`
+	Opponent string `json:"opponent"`
+	Reason   string `json:"reason"`
+}
+
+// A Rejection records why a challenger could not take a defender.
+type Rejection struct {
+	Defender string `json:"defender"`
+	Reason   string `json:"reason"`
+}
+
+// ParseGroups splits a comma separated list of group tags.
+func ParseGroups(value string) []string {
+	var groups []string
+	for _, group := range strings.Split(value, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// forbiddenReason returns why the two teams may never be paired, or an empty
+// string if nothing forbids it.
+func (round *Round) forbiddenReason(challenger string, defender string) string {
+	for _, pair := range round.Forbidden {
+		if (pair.Team == challenger && pair.Opponent == defender) ||
+			(pair.Team == defender && pair.Opponent == challenger) {
+			if pair.Reason == "" {
+				return "forbidden pairing"
+			}
+			return pair.Reason
+		}
+	}
+	for _, group := range round.Teams[challenger].Groups {
+		for _, other := range round.Teams[defender].Groups {
+			if group == other {
+				return fmt.Sprint("both teams belong to ", group)
+			}
+		}
+	}
+	return ""
+}
+
+func (round *Round) reject(challenger string, defender string, reason string) {
+	if round.Rejections == nil || defender == "" {
+		return
+	}
+	for _, rejection := range round.Rejections[challenger] {
+		if rejection.Defender == defender {
+			return
+		}
+	}
+	round.Rejections[challenger] = append(round.Rejections[challenger], Rejection{defender, reason})
+}
@@ -0,0 +1,193 @@
+package ladder
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A PlayedMatch is a match of an earlier round and, once reported, its
+// winner.
+type PlayedMatch struct {
+	Round      int    `json:"round"`
+	Challenger string `json:"challenger"`
+	Defender   string `json:"defender"`
+	// DefenderDivision is the division the defender was in at the time.
+	DefenderDivision string `json:"defender_division"`
+	Winner           string `json:"winner,omitempty"`
+}
+
+// History is the matches of the earlier rounds of a season.
+type History []PlayedMatch
+
+// NewHistory collects the matches of the recorded rounds. Of several records
+// of the same round, the last one counts.
+func NewHistory(records []RunRecord) History {
+	rounds := make(map[int]RunRecord)
+	for _, record := range records {
+		rounds[record.Round] = record
+	}
+	var history History
+	for _, record := range rounds {
+		history = append(history, record.PlayedMatches()...)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].Round != history[j].Round {
+			return history[i].Round < history[j].Round
+		}
+		return history[i].Challenger < history[j].Challenger
+	})
+	return history
+}
+
+// Recent returns the matches of the rounds rounds before current.
+func (history History) Recent(current int, rounds int) History {
+	var recent History
+	for _, match := range history {
+		if match.Round < current && match.Round >= current-rounds {
+			recent = append(recent, match)
+		}
+	}
+	return recent
+}
+
+// A CooldownRule rules out pairings from what happened in earlier rounds. It
+// is checked with the other pairing rules whenever a match is validated.
+type CooldownRule interface {
+	// CooldownRejection returns why challenger may not challenge defender
+	// this round, or an empty string if the rule allows it.
+	CooldownRejection(round *Round, challenger string, defender string) string
+}
+
+// A CooldownKind selects one of the built-in cooldown rules.
+type CooldownKind int
+
+const (
+	// CooldownProtectDefender protects a defender that won a defense.
+	CooldownProtectDefender CooldownKind = iota
+	// CooldownLoserDivision bars a challenger that lost from challenging the
+	// division of the team it lost to.
+	CooldownLoserDivision
+	// CooldownRematch bars a challenger from challenging the same defender
+	// again.
+	CooldownRematch
+)
+
+func (k CooldownKind) String() string {
+	switch k {
+	case CooldownProtectDefender:
+		return "protect-defender"
+	case CooldownLoserDivision:
+		return "loser-division"
+	case CooldownRematch:
+		return "rematch"
+	}
+	return fmt.Sprintf("CooldownKind(%d)", int(k))
+}
+
+// ParseCooldownKind is the inverse of CooldownKind.String.
+func ParseCooldownKind(value string) (CooldownKind, error) {
+	for _, k := range []CooldownKind{CooldownProtectDefender, CooldownLoserDivision, CooldownRematch} {
+		if value == k.String() {
+			return k, nil
+		}
+	}
+	return CooldownProtectDefender, Errorf(ErrParse, "parse cooldown", "unknown cooldown %q", value)
+}
+
+func (k CooldownKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *CooldownKind) UnmarshalText(text []byte) error {
+	value, err := ParseCooldownKind(string(text))
+	if err != nil {
+		return err
+	}
+	*k = value
+	return nil
+}
+
+// A Cooldown is a built-in CooldownRule applying to the matches of the last
+// Rounds rounds, or only the previous round if Rounds is 0. Unlike other
+// rules, Cooldowns are saved with a run.
+type Cooldown struct {
+	Kind   CooldownKind `json:"kind"`
+	Rounds int          `json:"rounds,omitempty"`
+}
+
+func (c Cooldown) CooldownRejection(round *Round, challenger string, defender string) string {
+	rounds := c.Rounds
+	if rounds < 1 {
+		rounds = 1
+	}
+	for _, match := range round.History.Recent(round.Current, rounds) {
+		switch c.Kind {
+		case CooldownProtectDefender:
+			if match.Defender == defender && match.Winner == defender {
+				return fmt.Sprint(defender, " defended successfully in round ", match.Round, " and is protected.")
+			}
+		case CooldownLoserDivision:
+			if match.Challenger == challenger && match.Winner == match.Defender && match.DefenderDivision == round.Teams[defender].Division {
+				return fmt.Sprint(challenger, " lost a challenge in round ", match.Round, " and may not challenge division ", match.DefenderDivision, ".")
+			}
+		case CooldownRematch:
+			if match.Challenger == challenger && match.Defender == defender {
+				return fmt.Sprint(challenger, " already challenged ", defender, " in round ", match.Round, ".")
+			}
+		}
+	}
+	return ""
+}
+
+// cooldownRejection returns the reason of the first cooldown rule ruling out
+// the pairing, or an empty string.
+func (round *Round) cooldownRejection(challenger string, defender string) string {
+	for _, rule := range round.Cooldowns {
+		if reason := rule.CooldownRejection(round, challenger, defender); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// PlayedMatches lists the valid matches of the recorded round with the
+// reported winners.
+func (record RunRecord) PlayedMatches() []PlayedMatch {
+	divisions := make(map[string]string)
+	for _, team := range record.Teams {
+		divisions[team.Name] = team.Division
+	}
+	var matches []PlayedMatch
+	for challenger, challenge := range record.Challenges {
+		if !challenge.ValidMatch {
+			continue
+		}
+		matches = append(matches, PlayedMatch{
+			Round:            record.Round,
+			Challenger:       challenger,
+			Defender:         challenge.Defender,
+			DefenderDivision: divisions[challenge.Defender],
+			Winner:           record.Results[challenger],
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Challenger < matches[j].Challenger
+	})
+	return matches
+}
+
+// SetResult reports the winner of the recorded match of challenger.
+func (record *RunRecord) SetResult(challenger string, winner string) error {
+	challenge := record.Challenges[challenger]
+	if challenge == nil || !challenge.ValidMatch {
+		return Errorf(ErrValidation, "set result", "%s has no match in round %d", challenger, record.Round)
+	}
+	if winner != challenger && winner != challenge.Defender {
+		return Errorf(ErrValidation, "set result", "%s did not play in %s vs %s", winner, challenger, challenge.Defender)
+	}
+	if record.Results == nil {
+		record.Results = make(map[string]string)
+	}
+	record.Results[challenger] = winner
+	return nil
+}
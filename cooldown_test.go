@@ -0,0 +1,49 @@
+package ladder
+
+import "testing"
+
+func TestCooldownRejection(t *testing.T) {
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 2, Name: "B", Division: "X"},
+		{Rank: 3, Name: "C", Division: "Y"},
+		{Rank: 4, Name: "D", Division: "Y"},
+	}
+	history := History{
+		{Round: 1, Challenger: "D", Defender: "B", DefenderDivision: "X", Winner: "B"},
+		{Round: 3, Challenger: "C", Defender: "A", DefenderDivision: "X", Winner: "A"},
+		{Round: 3, Challenger: "D", Defender: "C", DefenderDivision: "Y", Winner: "D"},
+	}
+	round, err := NewRound(teams, nil, Options{Round: 4, History: history})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		cooldown   Cooldown
+		challenger string
+		defender   string
+		rejected   bool
+	}{
+		{"protected defender", Cooldown{Kind: CooldownProtectDefender}, "B", "A", true},
+		{"defender that lost", Cooldown{Kind: CooldownProtectDefender}, "D", "C", false},
+		{"protection expired", Cooldown{Kind: CooldownProtectDefender}, "C", "B", false},
+		{"protection of several rounds", Cooldown{Kind: CooldownProtectDefender, Rounds: 3}, "C", "B", true},
+		{"loser's division", Cooldown{Kind: CooldownLoserDivision}, "C", "B", true},
+		{"loser's other division", Cooldown{Kind: CooldownLoserDivision}, "C", "D", false},
+		{"winner", Cooldown{Kind: CooldownLoserDivision}, "D", "A", false},
+		{"rematch", Cooldown{Kind: CooldownRematch}, "D", "C", true},
+		{"rematch expired", Cooldown{Kind: CooldownRematch}, "D", "B", false},
+		{"rematch of several rounds", Cooldown{Kind: CooldownRematch, Rounds: 3}, "D", "B", true},
+		{"other defender", Cooldown{Kind: CooldownRematch}, "D", "A", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reason := test.cooldown.CooldownRejection(round, test.challenger, test.defender)
+			if (reason != "") != test.rejected {
+				t.Errorf("%s vs %s: reason %q, want rejected %v", test.challenger, test.defender, reason, test.rejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package ladder
+
+// Give every team without preferences the round's default preference, and
+// list it in Defaulted. Teams whose late preferences were ignored count as
+// not having submitted.
+func (round *Round) applyDefaults(prefs map[string]*ProcessedPreference) {
+	round.Defaulted = nil
+	for _, team := range round.AscOrder {
+		if team == "" || prefs[team] != nil {
+			continue
+		}
+		round.Defaulted = append(round.Defaulted, team)
+		if round.DefaultPreference == nil && !round.Teams[team].ForceAccept {
+			round.trace(team, "submitted no preferences and sits out.")
+			continue
+		}
+		round.trace(team, "submitted no preferences and takes the default.")
+		pref := ProcessedPreference{Team: team, PrevChallenged: round.PrevOpponents[team]}
+		if def := round.DefaultPreference; def != nil {
+			pref.Accept = def.Accept
+			pref.Challenge = def.Challenge
+			pref.LastResortPref = def.LastResortPref
+		}
+		round.applyForceAccept(&pref)
+		if !pref.Accept {
+			round.Teams[team].Taken = true
+			round.Teams[team].TakenTwo = true
+		}
+		prefs[team] = &pref
+	}
+}
+
+// IsDefaulted reports whether team submitted no preferences this round.
+func (round *Round) IsDefaulted(team string) bool {
+	for _, defaulted := range round.Defaulted {
+		if defaulted == team {
+			return true
+		}
+	}
+	return false
+}
+
+// Inactivity returns, for every team, the number of consecutive rounds up to
+// and including this one in which it submitted no preferences.
+func (round *Round) Inactivity() map[string]int {
+	inactivity := make(map[string]int)
+	for name, team := range round.Teams {
+		if round.IsDefaulted(name) {
+			inactivity[name] = team.Inactivity + 1
+		} else {
+			inactivity[name] = 0
+		}
+	}
+	return inactivity
+}
@@ -0,0 +1,36 @@
+package ladder
+
+import "testing"
+
+func TestDefaultPreferenceKeepsPrevOpponent(t *testing.T) {
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 2, Name: "B", Division: "X"},
+		{Rank: 3, Name: "C", Division: "A"},
+	}
+	prefs := []RawPreference{
+		{Team: "A", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "B", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+	}
+	result, err := Resolve(teams, prefs, Options{
+		Round:             2,
+		DefaultPreference: &ProcessedPreference{Accept: true, Challenge: true, LastResortPref: MinRank},
+		PrevOpponents:     map[string]string{"C": "B"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Round.Defaulted; len(got) != 1 || got[0] != "C" {
+		t.Fatalf("Defaulted = %v, want [C]", got)
+	}
+	challenge := result.Round.Chals["C"]
+	if challenge == nil || !challenge.ValidMatch {
+		t.Fatal("C has no match")
+	}
+	if challenge.Defender != "A" {
+		t.Errorf("C challenges %s, want A since it challenged B last round", challenge.Defender)
+	}
+	if got := result.Round.Inactivity()["C"]; got != 1 {
+		t.Errorf("inactivity of C = %d, want 1", got)
+	}
+}
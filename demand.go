@@ -0,0 +1,126 @@
+package ladder
+
+// A Contender is a challenger naming a defender among its picks.
+type Contender struct {
+	Challenger string `json:"challenger"`
+	Rank       int    `json:"rank"`
+	New        bool   `json:"new"`
+	// Pick is the position of the defender in the challenger's picks,
+	// starting at 1.
+	Pick int `json:"pick"`
+	// Priority is the challenger's turn in the resolution, starting at 1.
+	Priority int `json:"priority"`
+	// Reason is why the pick can never succeed, if it cannot.
+	Reason string `json:"reason,omitempty"`
+}
+
+// A Demand is how contested a defender is.
+type Demand struct {
+	Defender string `json:"defender"`
+	Rank     int    `json:"rank"`
+	// Slots is the number of challengers the defender can take.
+	Slots int `json:"slots"`
+	// Picks counts the contenders by position, Picks[0] naming the defender
+	// first.
+	Picks []int `json:"picks"`
+	// Contenders are in priority order.
+	Contenders []Contender `json:"contenders"`
+	// Winners are the challengers holding the defender after resolution.
+	Winners []string `json:"winners"`
+}
+
+// Contested reports whether more challengers could take the defender than it
+// has slots for.
+func (demand Demand) Contested() bool {
+	valid := 0
+	for _, contender := range demand.Contenders {
+		if contender.Reason == "" {
+			valid++
+		}
+	}
+	return valid > demand.Slots
+}
+
+// PriorityOrder returns the challengers in the order generateChallenges gives
+// them a match: new teams first, then from the bottom of the ladder up. With
+// LateLowestPriority, late teams come after everyone else in the same order.
+func (round *Round) PriorityOrder() []string {
+	var order, late []string
+	for _, teams := range [][]string{round.NewTeams, round.DescOrder} {
+		for _, team := range teams {
+			if team == "" {
+				continue
+			}
+			if round.LatePolicy == LateLowestPriority && round.lateTeam(team) {
+				late = append(late, team)
+			} else {
+				order = append(order, team)
+			}
+		}
+	}
+	return append(order, late...)
+}
+
+func (round *Round) slots(team string) int {
+	if round.Teams[team].Rank == 1 {
+		return 2
+	}
+	return 1
+}
+
+// Demand lists, in ladder order, every defender named by a challenger. On a
+// resolved round, Winners are the challengers that got the defender.
+func (round *Round) Demand() []Demand {
+	demands := make(map[string]*Demand)
+	for priority, challenger := range round.PriorityOrder() {
+		pref := round.Prefs[challenger]
+		if pref == nil || !pref.Challenge {
+			continue
+		}
+		for i, defender := range pref.Picks {
+			if round.Teams[defender] == nil {
+				continue
+			}
+			demand := demands[defender]
+			if demand == nil {
+				demand = &Demand{
+					Defender: defender,
+					Rank:     round.Teams[defender].Rank,
+					Slots:    round.slots(defender),
+					Winners:  []string{},
+				}
+				demands[defender] = demand
+			}
+			for len(demand.Picks) <= i {
+				demand.Picks = append(demand.Picks, 0)
+			}
+			demand.Picks[i]++
+			demand.Contenders = append(demand.Contenders, Contender{
+				Challenger: challenger,
+				Rank:       round.Teams[challenger].Rank,
+				New:        round.Teams[challenger].New,
+				Pick:       i + 1,
+				Priority:   priority + 1,
+				Reason:     round.StaticRejection(challenger, defender),
+			})
+		}
+	}
+
+	for _, challenger := range round.AscOrder {
+		challenge := round.Chals[challenger]
+		if challenger == "" || challenge == nil || !challenge.ValidMatch {
+			continue
+		}
+		if demand := demands[challenge.Defender]; demand != nil {
+			demand.Winners = append(demand.Winners, challenger)
+		}
+	}
+
+	var sorted []Demand
+	for _, defender := range round.AscOrder {
+		if demand := demands[defender]; defender != "" && demand != nil {
+			sorted = append(sorted, *demand)
+		}
+	}
+	return sorted
+}
@@ -0,0 +1,53 @@
+package ladder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDemand(t *testing.T) {
+	round := overrideFixture(t, Options{})
+	if got, want := round.PriorityOrder(), []string{"F", "E", "D", "C", "B", "A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PriorityOrder() = %v, want %v", got, want)
+	}
+
+	want := []Demand{
+		{Defender: "A", Rank: 1, Slots: 2, Picks: []int{0, 2},
+			Contenders: []Contender{{Challenger: "D", Rank: 4, Pick: 2, Priority: 3}, {Challenger: "C", Rank: 3, Pick: 2, Priority: 4}},
+			Winners:    []string{"C"}},
+		{Defender: "B", Rank: 2, Slots: 1, Picks: []int{2},
+			Contenders: []Contender{{Challenger: "D", Rank: 4, Pick: 1, Priority: 3}, {Challenger: "C", Rank: 3, Pick: 1, Priority: 4}},
+			Winners:    []string{"D"}},
+		{Defender: "C", Rank: 3, Slots: 1, Picks: []int{1},
+			Contenders: []Contender{{Challenger: "E", Rank: 5, Pick: 1, Priority: 2}},
+			Winners:    []string{"E"}},
+		{Defender: "D", Rank: 4, Slots: 1, Picks: []int{1},
+			Contenders: []Contender{{Challenger: "F", Rank: 6, Pick: 1, Priority: 1}},
+			Winners:    []string{"F"}},
+	}
+	got := round.Demand()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Demand() = %+v, want %+v", got, want)
+	}
+	for _, demand := range got {
+		if contested := demand.Contested(); contested != (demand.Defender == "B") {
+			t.Errorf("%s contested = %v", demand.Defender, contested)
+		}
+	}
+
+	// A contender that can never take the defender does not contest it.
+	round = overrideFixture(t, Options{Forbidden: []ForbiddenPair{{Team: "C", Opponent: "B", Reason: "dispute"}}})
+	for _, demand := range round.Demand() {
+		if demand.Defender != "B" {
+			continue
+		}
+		for _, contender := range demand.Contenders {
+			if (contender.Reason != "") != (contender.Challenger == "C") {
+				t.Errorf("contender %s of B has reason %q", contender.Challenger, contender.Reason)
+			}
+		}
+		if demand.Contested() {
+			t.Errorf("B is contested by %+v", demand.Contenders)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package ladder
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors, to be checked with errors.Is.
+var (
+	// ErrAuth means the credentials or token could not be read or used.
+	ErrAuth = errors.New("authentication failed")
+	// ErrNetwork means a remote service could not be reached or refused the
+	// request. Retrying later may succeed.
+	ErrNetwork = errors.New("network error")
+	// ErrParse means the input data is malformed.
+	ErrParse = errors.New("unable to parse input")
+	// ErrValidation means the input is well formed but cannot be resolved,
+	// e.g. two teams share a rank or an override is invalid.
+	ErrValidation = errors.New("invalid input")
+)
+
+// An Error is an error of a known kind, raised by the operation Op.
+type Error struct {
+	Kind error
+	Op   string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Op + ": " + e.Kind.Error()
+	}
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is reports whether target is the kind of e, so that
+// errors.Is(err, ErrNetwork) works on wrapped errors.
+func (e *Error) Is(target error) bool { return target == e.Kind }
+
+// Errorf returns an *Error of the given kind.
+func Errorf(kind error, op string, format string, a ...interface{}) error {
+	return &Error{Kind: kind, Op: op, Err: fmt.Errorf(format, a...)}
+}
+
+// WrapError returns err as an *Error of the given kind, or nil if err is nil.
+func WrapError(kind error, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Op: op, Err: err}
+}
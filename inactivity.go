@@ -0,0 +1,293 @@
+package ladder
+
+import (
+	"fmt"
+	"sort"
+)
+
+// An InactivityTrigger is the behaviour an InactivityRule counts.
+type InactivityTrigger int
+
+const (
+	// TriggerDeclined counts the rounds a team did not accept challenges,
+	// whether it declined or submitted nothing.
+	TriggerDeclined InactivityTrigger = iota
+	// TriggerMissing counts the rounds a team submitted nothing.
+	TriggerMissing
+)
+
+func (t InactivityTrigger) String() string {
+	switch t {
+	case TriggerDeclined:
+		return "declined"
+	case TriggerMissing:
+		return "missing"
+	}
+	return fmt.Sprintf("InactivityTrigger(%d)", int(t))
+}
+
+// ParseInactivityTrigger is the inverse of InactivityTrigger.String.
+func ParseInactivityTrigger(value string) (InactivityTrigger, error) {
+	for _, t := range []InactivityTrigger{TriggerDeclined, TriggerMissing} {
+		if value == t.String() {
+			return t, nil
+		}
+	}
+	return TriggerDeclined, Errorf(ErrParse, "parse inactivity trigger", "unknown inactivity trigger %q", value)
+}
+
+func (t InactivityTrigger) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *InactivityTrigger) UnmarshalText(text []byte) error {
+	value, err := ParseInactivityTrigger(string(text))
+	if err != nil {
+		return err
+	}
+	*t = value
+	return nil
+}
+
+// A PenaltyKind is what an InactivityRule does to a team.
+type PenaltyKind int
+
+const (
+	// PenaltyDrop moves the team down the ladder.
+	PenaltyDrop PenaltyKind = iota
+	// PenaltyForceAccept makes the team accept challenges next round
+	// whatever it answers.
+	PenaltyForceAccept
+)
+
+func (k PenaltyKind) String() string {
+	switch k {
+	case PenaltyDrop:
+		return "drop"
+	case PenaltyForceAccept:
+		return "force-accept"
+	}
+	return fmt.Sprintf("PenaltyKind(%d)", int(k))
+}
+
+// ParsePenaltyKind is the inverse of PenaltyKind.String.
+func ParsePenaltyKind(value string) (PenaltyKind, error) {
+	for _, k := range []PenaltyKind{PenaltyDrop, PenaltyForceAccept} {
+		if value == k.String() {
+			return k, nil
+		}
+	}
+	return PenaltyDrop, Errorf(ErrParse, "parse penalty", "unknown penalty %q", value)
+}
+
+func (k PenaltyKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *PenaltyKind) UnmarshalText(text []byte) error {
+	value, err := ParsePenaltyKind(string(text))
+	if err != nil {
+		return err
+	}
+	*k = value
+	return nil
+}
+
+// An InactivityRule penalizes a team every time its Trigger has held for
+// Rounds consecutive rounds, so a team declining for twice as long is
+// penalized twice.
+type InactivityRule struct {
+	Trigger InactivityTrigger `json:"trigger"`
+	Rounds  int               `json:"rounds"`
+	Penalty PenaltyKind       `json:"penalty"`
+	// Drop is the number of ranks a PenaltyDrop moves the team down.
+	Drop int `json:"drop,omitempty"`
+}
+
+func (rule InactivityRule) String() string {
+	s := fmt.Sprintf("%s for %d round(s): %s", rule.Trigger, rule.Rounds, rule.Penalty)
+	if rule.Penalty == PenaltyDrop {
+		s += fmt.Sprintf(" %d", rule.Drop)
+	}
+	return s
+}
+
+// A Penalty is an InactivityRule applied to a team.
+type Penalty struct {
+	Team string         `json:"team"`
+	Rule InactivityRule `json:"rule"`
+	// Streak is the number of consecutive rounds the trigger held.
+	Streak  int `json:"streak"`
+	Rank    int `json:"rank"`
+	NewRank int `json:"new_rank"`
+}
+
+// String is the audit line of the penalty.
+func (p Penalty) String() string {
+	switch p.Rule.Penalty {
+	case PenaltyDrop:
+		return fmt.Sprintf("%s %s for %d round(s) in a row: dropped from %d to %d", p.Team, p.Rule.Trigger, p.Streak, p.Rank, p.NewRank)
+	case PenaltyForceAccept:
+		return fmt.Sprintf("%s %s for %d round(s) in a row: must accept challenges next round", p.Team, p.Rule.Trigger, p.Streak)
+	}
+	return fmt.Sprintf("%s %s for %d round(s) in a row: %s", p.Team, p.Rule.Trigger, p.Streak, p.Rule.Penalty)
+}
+
+// ApplyInactivityRules produces the teams of the next round from teams, with
+// the rules applied to the rounds recorded in history. Of several records of
+// the same round, the last one counts. The teams are copied, and their
+// Inactivity is taken from the latest record.
+func ApplyInactivityRules(teams []Team, history []RunRecord, rules []InactivityRule) ([]Team, []Penalty, error) {
+	for _, rule := range rules {
+		if rule.Rounds < 1 {
+			return nil, nil, Errorf(ErrValidation, "apply inactivity rules", "rule %q needs at least one round", rule)
+		}
+		if rule.Penalty == PenaltyDrop && rule.Drop < 1 {
+			return nil, nil, Errorf(ErrValidation, "apply inactivity rules", "rule %q drops no ranks", rule)
+		}
+	}
+
+	rounds := make(map[int]RunRecord)
+	for _, record := range history {
+		rounds[record.Round] = record
+	}
+	var numbers []int
+	for number := range rounds {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	streaks := make(map[InactivityTrigger]map[string]int)
+	for _, trigger := range []InactivityTrigger{TriggerDeclined, TriggerMissing} {
+		streaks[trigger] = make(map[string]int)
+	}
+	var latest *RunRecord
+	for i, number := range numbers {
+		record := rounds[number]
+		latest = &record
+		// A streak only runs over consecutive rounds.
+		if i > 0 && number != numbers[i-1]+1 {
+			for _, streak := range streaks {
+				for name := range streak {
+					delete(streak, name)
+				}
+			}
+		}
+		round, err := NewRound(record.Teams, record.Prefs, record.Options())
+		if err != nil {
+			return nil, nil, fmt.Errorf("round %d of the history: %w", number, err)
+		}
+		for trigger, streak := range streaks {
+			for name := range streak {
+				if round.Teams[name] == nil {
+					delete(streak, name)
+				}
+			}
+			for name := range round.Teams {
+				var held bool
+				switch trigger {
+				case TriggerDeclined:
+					held = round.Prefs[name] == nil || !round.Prefs[name].Accept
+				case TriggerMissing:
+					held = round.IsDefaulted(name)
+				}
+				if held {
+					streak[name]++
+				} else {
+					streak[name] = 0
+				}
+			}
+		}
+	}
+
+	next := make([]Team, len(teams))
+	copy(next, teams)
+	var penalties []Penalty
+	drops := make(map[string]int)
+	for i := range next {
+		team := &next[i]
+		team.ForceAccept = false
+		if latest != nil {
+			team.Inactivity = latest.Inactivity[team.Name]
+		}
+		for _, rule := range rules {
+			streak := streaks[rule.Trigger][team.Name]
+			if streak == 0 || streak%rule.Rounds != 0 {
+				continue
+			}
+			penalties = append(penalties, Penalty{Team: team.Name, Rule: rule, Streak: streak, Rank: team.Rank, NewRank: team.Rank})
+			switch rule.Penalty {
+			case PenaltyDrop:
+				if !team.New {
+					drops[team.Name] += rule.Drop
+				}
+			case PenaltyForceAccept:
+				team.ForceAccept = true
+			}
+		}
+	}
+
+	ranks := dropTeams(next, drops)
+	for i := range next {
+		if rank, ok := ranks[next[i].Name]; ok {
+			next[i].Rank = rank
+			next[i].MAC = DivisionMAC(next[i].Division, rank)
+		}
+	}
+	for i := range penalties {
+		if penalties[i].Rule.Penalty == PenaltyDrop {
+			penalties[i].NewRank = ranks[penalties[i].Team]
+		}
+	}
+	return next, penalties, nil
+}
+
+// Move every team in drops down by its number of ranks and return the new
+// rank of every ranked team. Each dropped team is placed after the teams
+// whose rank is at most its target; dropped teams with the same target keep
+// their relative order.
+func dropTeams(teams []Team, drops map[string]int) map[string]int {
+	var ladder []Team
+	for _, team := range teams {
+		if !team.New {
+			ladder = append(ladder, team)
+		}
+	}
+	sort.Slice(ladder, func(i, j int) bool {
+		return ladder[i].Rank < ladder[j].Rank
+	})
+
+	type placement struct {
+		name    string
+		target  int
+		dropped bool
+	}
+	placements := make([]placement, len(ladder))
+	for i, team := range ladder {
+		target := i + drops[team.Name]
+		if target > len(ladder)-1 {
+			target = len(ladder) - 1
+		}
+		placements[i] = placement{team.Name, target, drops[team.Name] > 0}
+	}
+	sort.SliceStable(placements, func(i, j int) bool {
+		if placements[i].target != placements[j].target {
+			return placements[i].target < placements[j].target
+		}
+		return !placements[i].dropped && placements[j].dropped
+	})
+
+	ranks := make(map[string]int)
+	for i, p := range placements {
+		ranks[p.name] = i + 1
+	}
+	return ranks
+}
+
+// Make a team penalized with PenaltyForceAccept accept challenges.
+func (round *Round) applyForceAccept(pref *ProcessedPreference) {
+	if round.Teams[pref.Team].ForceAccept && !pref.Accept {
+		round.trace(pref.Team, "must accept challenges this round.")
+		pref.Accept = true
+	}
+}
@@ -0,0 +1,71 @@
+package ladder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDropTeams(t *testing.T) {
+	teams := []Team{
+		{Rank: 1, Name: "A"},
+		{Rank: 2, Name: "B"},
+		{Rank: 3, Name: "C"},
+		{Rank: 4, Name: "D"},
+		{Name: "N", New: true},
+	}
+	tests := []struct {
+		name  string
+		drops map[string]int
+		want  map[string]int
+	}{
+		{"none", nil, map[string]int{"A": 1, "B": 2, "C": 3, "D": 4}},
+		{"one", map[string]int{"A": 1}, map[string]int{"B": 1, "A": 2, "C": 3, "D": 4}},
+		{"past the bottom", map[string]int{"B": 5}, map[string]int{"A": 1, "C": 2, "D": 3, "B": 4}},
+		{"overlapping", map[string]int{"B": 2, "C": 1}, map[string]int{"A": 1, "D": 2, "B": 3, "C": 4}},
+		{"over each other", map[string]int{"A": 3, "B": 1}, map[string]int{"C": 1, "B": 2, "D": 3, "A": 4}},
+		{"same target", map[string]int{"A": 2, "B": 1}, map[string]int{"C": 1, "A": 2, "B": 3, "D": 4}},
+		{"all", map[string]int{"A": 1, "B": 1, "C": 1, "D": 1}, map[string]int{"A": 1, "B": 2, "C": 3, "D": 4}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := dropTeams(teams, test.drops); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("dropTeams(%v) = %v, want %v", test.drops, got, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyInactivityRulesResetsStreakAfterGap(t *testing.T) {
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 2, Name: "B", Division: "X"},
+	}
+	prefs := []RawPreference{
+		{Team: "B", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+	}
+	rules := []InactivityRule{{Trigger: TriggerMissing, Rounds: 2, Penalty: PenaltyDrop, Drop: 1}}
+
+	tests := []struct {
+		name    string
+		rounds  []int
+		dropped bool
+	}{
+		{"consecutive", []int{1, 2}, true},
+		{"gap", []int{1, 3}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var history []RunRecord
+			for _, number := range test.rounds {
+				history = append(history, RunRecord{Round: number, Teams: teams, Prefs: prefs})
+			}
+			next, penalties, err := ApplyInactivityRules(teams, history, rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dropped := len(penalties) == 1 && next[0].Rank == 2; dropped != test.dropped {
+				t.Errorf("A dropped = %v, want %v (penalties %v)", dropped, test.dropped, penalties)
+			}
+		})
+	}
+}
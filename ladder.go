@@ -1,31 +1,89 @@
-package main
+// Package ladder resolves challenges for Spladder-like Splatoon ladder
+// tournaments.
+package ladder
 
 import (
-	"flag"
 	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"time"
 )
 
 const MaxParticipants = 1000
 
 type Round struct {
-	Teams     map[string]*Team
-	NewTeams  []string
-	AscOrder  []string
-	DescOrder []string
-	Prefs     map[string]*ProcessedPreference
-	Chals     map[string]*Challenge
-	Current   int
+	Teams      map[string]*Team
+	NewTeams   []string
+	AscOrder   []string
+	DescOrder  []string
+	Prefs      map[string]*ProcessedPreference
+	Chals      map[string]*Challenge
+	Current    int
+	Forbidden  []ForbiddenPair
+	Rejections map[string][]Rejection
+
+	// Reject pairings of teams whose rosters share a player, with each other
+	// or with a team of another match.
+	AvoidSharedPlayers bool
+
+	// MaxPicks limits the picks of every team, if positive.
+	MaxPicks int
+
+	// Cooldowns rule out pairings from the matches of earlier rounds in
+	// History.
+	Cooldowns []CooldownRule
+	History   History
+
+	// Give the defenders freed by a withdrawal to challengers without a match.
+	Refill bool
+
+	// Results maps challengers to the reported winners of their matches.
+	Results map[string]string
+
+	// Preferences submitted after the deadline are handled by LatePolicy and
+	// listed in Late.
+	Deadline   time.Time
+	LatePolicy LatePolicy
+	Late       []LateEntry
+
+	// Teams without preferences are listed in Defaulted and take
+	// DefaultPreference, or sit out the round if it is nil.
+	DefaultPreference *ProcessedPreference
+	Defaulted         []string
+	// PrevOpponents maps teams to their previous opponent, for the
+	// preferences that name none.
+	PrevOpponents map[string]string
+
+	// Everything needed to reproduce the run.
+	Seed        int64
+	RawPrefs    []RawPreference
+	ManualPicks []ManualPick
+	Overrides   []string
+
+	// Log receives a trace of the resolution. Nothing is written if nil.
+	Log io.Writer
+
+	manualAssign func(round *Round, deferredTeams []string)
+	replay       bool
 }
 
 type Team struct {
-	Rank     int    `json:"rank"`
-	PrevRank int    `json:"prev_rank"`
-	Name     string `json:"team"`
-	Division string `json:"division"`
-	New      bool   `json:"new"`
-	Taken    bool
-	TakenTwo bool
-	MAC      int
+	Rank     int      `json:"rank"`
+	PrevRank int      `json:"prev_rank"`
+	Name     string   `json:"team"`
+	Division string   `json:"division"`
+	New      bool     `json:"new"`
+	Groups   []string `json:"groups"`
+	Roster   []Player `json:"roster"`
+	// Inactivity counts the consecutive rounds before this one in which the
+	// team submitted no preferences.
+	Inactivity int `json:"inactivity"`
+	// ForceAccept makes the team accept challenges whatever it answers.
+	ForceAccept bool `json:"force_accept,omitempty"`
+	Taken       bool
+	TakenTwo    bool
+	MAC         int
 }
 
 type RawPreference struct {
@@ -34,9 +92,11 @@ type RawPreference struct {
 	Challenge      string `json:"challenge"`
 	PrevChallenged string `json:"prev_challenged"`
 	LastResortPref string `json:"last_resort"`
-	First          string `json:"first"`
-	Second         string `json:"second"`
-	Third          string `json:"third"`
+	// Picks are the defenders the team wants to challenge, most wanted
+	// first.
+	Picks []string `json:"picks"`
+	// SubmittedAt is nil if the submission time is unknown.
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
 }
 
 type LastResortChallenge int
@@ -49,14 +109,13 @@ const (
 )
 
 type ProcessedPreference struct {
-	Team           string
-	Accept         bool
-	Challenge      bool
-	PrevChallenged string
-	LastResortPref LastResortChallenge
-	First          string
-	Second         string
-	Third          string
+	Team           string              `json:"team"`
+	Accept         bool                `json:"accept"`
+	Challenge      bool                `json:"challenge"`
+	PrevChallenged string              `json:"prev_challenged"`
+	LastResortPref LastResortChallenge `json:"last_resort"`
+	Picks          []string            `json:"picks"`
+	SubmittedAt    *time.Time          `json:"submitted_at,omitempty"`
 }
 
 type Challenge struct {
@@ -67,14 +126,17 @@ type Challenge struct {
 	ChallengerRank int
 	Defender       string
 	DefenderRank   int
+	Pinned         bool
 }
 
-func (round *Round) initRound(currentRound int) {
+// Set up the round from loaded inputs. The result depends only on the inputs
+// and round.Seed.
+func (round *Round) setupRound(teams map[string]*Team, rawPrefs []RawPreference, currentRound int) {
 	// 1. Load teams.
 
-	teams := getTeamsFromSpreadsheet()
-	fmt.Println("Loaded teams:", len(teams))
+	round.trace("Loaded teams:", len(teams))
 	round.Teams = teams
+	round.RawPrefs = rawPrefs
 
 	// 2. Sort teams by priority
 
@@ -94,6 +156,13 @@ func (round *Round) initRound(currentRound int) {
 		i--
 	}
 
+	// New teams have no rank, so their priority is drawn from the seed.
+	sort.Strings(newTeams)
+	rng := rand.New(rand.NewSource(round.Seed))
+	rng.Shuffle(len(newTeams), func(i, j int) {
+		newTeams[i], newTeams[j] = newTeams[j], newTeams[i]
+	})
+
 	for _, team := range newTeams {
 		sortedTeams = append(sortedTeams, team)
 	}
@@ -103,24 +172,22 @@ func (round *Round) initRound(currentRound int) {
 	round.DescOrder = descSortedTeams
 
 	// 3. Load preferences.
-	rawPrefs := getPrefsFromSpreadsheet()
 
 	prefs := make(map[string]*ProcessedPreference)
 
+	round.Late = nil
 	for _, rawPref := range rawPrefs {
-		var pref ProcessedPreference
-
-		pref.Team = rawPref.Team
-		pref.PrevChallenged = rawPref.PrevChallenged
-		pref.First = rawPref.First
-		pref.Second = rawPref.Second
-		pref.Third = rawPref.Third
-
-		switch rawPref.Accept {
-		case "受け付ける":
-			pref.Accept = true
-		case "受け付けない":
-			pref.Accept = false
+		pref := ParsePreference(rawPref)
+		if pref.PrevChallenged == "" {
+			pref.PrevChallenged = round.PrevOpponents[pref.Team]
+		}
+		if !round.applyLatePolicy(&pref) {
+			continue
+		}
+		round.applyForceAccept(&pref)
+		if round.MaxPicks > 0 && len(pref.Picks) > round.MaxPicks {
+			round.trace("Ignoring the picks of", pref.Team, "after the first", round.MaxPicks)
+			pref.Picks = pref.Picks[:round.MaxPicks]
 		}
 
 		if pref.Accept == false {
@@ -128,79 +195,119 @@ func (round *Round) initRound(currentRound int) {
 			round.Teams[pref.Team].TakenTwo = true
 		}
 
-		switch rawPref.Challenge {
-		case "行う":
-			pref.Challenge = true
-		case "行わない":
-			pref.Challenge = false
-		}
-
-		switch rawPref.LastResortPref {
-		case "どこにもチャレンジしない":
-			pref.LastResortPref = None
-		case "チャレンジ可能な範囲で一番順位の低いチームにチャレンジする":
-			pref.LastResortPref = MinRank
-		case "チャレンジ可能な範囲で一番順位の高いチームにチャレンジする":
-			pref.LastResortPref = MaxRank
-		case "自分より上位のチームならどこでもいいからチャレンジする":
-			pref.LastResortPref = Any
-		}
-
 		prefs[pref.Team] = &pref
 	}
+	round.applyDefaults(prefs)
 
-	fmt.Println("Loaded prefs:", len(prefs))
+	round.trace("Loaded prefs:", len(prefs))
 	round.Prefs = prefs
 
 	round.Current = currentRound
 }
 
+func (round *Round) trace(a ...interface{}) {
+	if round.Log != nil {
+		fmt.Fprintln(round.Log, a...)
+	}
+}
+
 func (round *Round) validateMatch(challenger string, defender string, ignoreMac bool) bool {
-	fmt.Println("Validating", challenger, "vs", defender)
+	round.trace("Validating", challenger, "vs", defender)
+	if reason := round.MatchRejection(challenger, defender, ignoreMac); reason != "" {
+		round.trace(reason)
+		round.reject(challenger, defender, reason)
+		return false
+	}
+	return true
+}
+
+// MatchRejection returns why challenger cannot take defender, or an empty
+// string if the match is valid. It writes nothing to the log, so it is safe to
+// call for every team when building listings.
+func (round *Round) MatchRejection(challenger string, defender string, ignoreMac bool) string {
+	if reason := round.pairingRejection(challenger, defender); reason != "" {
+		return reason
+	}
+	// Is the defender team taken?
+	if round.CheckTaken(defender) == true {
+		return fmt.Sprint(defender, " is taken.")
+	}
+	// Does either team share a player with a team playing another match?
+	if round.AvoidSharedPlayers {
+		if reason := round.scheduledSharedPlayers(challenger, defender); reason != "" {
+			return reason
+		}
+	}
+	return round.rankRejection(challenger, defender, ignoreMac)
+}
+
+// StaticRejection is MatchRejection without the checks that depend on the
+// matches made so far, i.e. whether the defender is already taken. New
+// challengers are exempt from the MAC as they are during resolution.
+func (round *Round) StaticRejection(challenger string, defender string) string {
+	if reason := round.pairingRejection(challenger, defender); reason != "" {
+		return reason
+	}
+	return round.rankRejection(challenger, defender, round.Teams[challenger].New)
+}
+
+func (round *Round) pairingRejection(challenger string, defender string) string {
 	teams := round.Teams
 	prefs := round.Prefs
 
 	// Do these teams exist?
 	if teams[challenger] == nil {
-		fmt.Println(challenger, "does not exist.")
-		return false
+		return fmt.Sprint(challenger, " does not exist.")
 	}
 	if teams[defender] == nil {
-		fmt.Println(defender, "does not exist.")
-		return false
+		return fmt.Sprint(defender, " does not exist.")
 	}
 	// Is the defender accepting matches?
-	if prefs[defender].Accept == false {
-		fmt.Println(defender, "is not accepting challenges.")
-		return false
+	if prefs[defender] == nil || prefs[defender].Accept == false {
+		return fmt.Sprint(defender, " is not accepting challenges.")
 	}
 	// Did the challenger challenge defender in the previous round?
-	if prefs[challenger].PrevChallenged != "" {
+	if prefs[challenger] != nil && prefs[challenger].PrevChallenged != "" {
 		if prefs[challenger].PrevChallenged == teams[defender].Name {
-			fmt.Println(challenger, "already challenged", defender, "last round.")
-			return false
+			return fmt.Sprint(challenger, " already challenged ", defender, " last round.")
 		}
 	}
-	// Is the defender team taken?
-	if round.checkTaken(defender) == true {
-		fmt.Println(defender, "is taken.")
-		return false
+	return round.ruleRejection(challenger, defender)
+}
+
+// ruleRejection covers the pairings ruled out by the TO rather than by the
+// ladder or the teams' preferences.
+func (round *Round) ruleRejection(challenger string, defender string) string {
+	// Are these teams allowed to play each other at all?
+	if reason := round.forbiddenReason(challenger, defender); reason != "" {
+		return fmt.Sprint(challenger, " and ", defender, " cannot be paired: ", reason)
+	}
+	// Do these teams share a player?
+	if round.AvoidSharedPlayers {
+		if shared := round.sharedPlayers(challenger, defender); len(shared) > 0 {
+			return fmt.Sprint(challenger, " and ", defender, " share players: ", shared)
+		}
 	}
+	// Is either team cooling down from an earlier round?
+	return round.cooldownRejection(challenger, defender)
+}
+
+func (round *Round) rankRejection(challenger string, defender string, ignoreMac bool) string {
+	teams := round.Teams
+
 	// Is the challenger's rank lower than defender's rank?
 	if teams[challenger].Rank < teams[defender].Rank {
-		fmt.Println("Challenging", challenger, "rank is higher than defending", defender)
-		return false
+		return fmt.Sprint("Challenging ", challenger, " rank is higher than defending ", defender)
 	}
 	// Is the defender's rank too high to be challenged?
 	if ignoreMac == false && teams[defender].MAC < teams[challenger].Rank {
-		fmt.Println(defender, "rank is too high to be challenged.")
-		return false
+		return fmt.Sprint(defender, " rank is too high to be challenged.")
 	}
-
-	return true
+	return ""
 }
 
-func (round *Round) checkTaken(team string) bool {
+// CheckTaken reports whether the team has no free slot left to defend.
+func (round *Round) CheckTaken(team string) bool {
 	teams := round.Teams
 	// If not 1st, then just look at Taken
 	if teams[team] != nil {
@@ -228,27 +335,39 @@ func (round *Round) takeTeam(challenger string, defender string, challenge *Chal
 	} else {
 		teams[defender].Taken = true
 	}
-	fmt.Println("Challenge accepted: ", challenge.ChallengerRank, "位", challenge.Challenger, "vs", challenge.DefenderRank, "位", challenge.Defender)
+	round.trace("Challenge accepted: ", challenge.ChallengerRank, "位", challenge.Challenger, "vs", challenge.DefenderRank, "位", challenge.Defender)
+}
+
+// lastRankAbove returns the lowest rank above rank held by a ranked team. New
+// teams may carry a rank past the ranked teams.
+func (round *Round) lastRankAbove(rank int) int {
+	if last := len(round.Teams) - len(round.NewTeams); rank-1 > last {
+		return last
+	}
+	return rank - 1
 }
 
 func (round *Round) challengeMinRank(challenge *Challenge) {
 	teams := round.Teams
 	ascSortedTeams := round.AscOrder
 	challengerRank := teams[challenge.Challenger].Rank
-	fmt.Println("Trying to find an opponent. Challenger rank is ", challengerRank)
+	round.trace("Trying to find an opponent. Challenger rank is ", challengerRank)
 
-	for i := challengerRank - 1; i > 0; i-- {
+	for i := round.lastRankAbove(challengerRank); i > 0; i-- {
 		team := ascSortedTeams[i]
-		fmt.Println("Checking if the following team is good:", team)
+		if team == "" {
+			continue
+		}
+		round.trace("Checking if the following team is good:", team)
 		if teams[team].MAC < challengerRank {
-			fmt.Println("No, ranking too high. No valid match for", challenge.Challenger)
+			round.trace("No, ranking too high. No valid match for", challenge.Challenger)
 			challenge.ValidMatch = false
 			break
 		}
 		if round.validateMatch(challenge.Challenger, team, false) == false {
-			fmt.Println("Invalid match.")
+			round.trace("Invalid match.")
 		} else {
-			fmt.Println("Minimum rank opponent available.")
+			round.trace("Minimum rank opponent available.")
 			round.takeTeam(challenge.Challenger, team, challenge)
 			break
 		}
@@ -259,192 +378,158 @@ func (round *Round) challengeMaxRank(challenge *Challenge) {
 	teams := round.Teams
 	ascSortedTeams := round.AscOrder
 	challengerRank := teams[challenge.Challenger].Rank
-	fmt.Println("Trying to find an opponent. Challenger rank is ", challengerRank)
+	round.trace("Trying to find an opponent. Challenger rank is ", challengerRank)
 
-	for i := 1; i < challengerRank; i++ {
+	for i := 1; i <= round.lastRankAbove(challengerRank); i++ {
 		team := ascSortedTeams[i]
-		fmt.Println("Checking if the following team is good:", team)
+		if team == "" {
+			continue
+		}
+		round.trace("Checking if the following team is good:", team)
 		if teams[team].MAC < challengerRank {
-			fmt.Println("No, ranking too high")
+			round.trace("No, ranking too high")
 		} else if round.validateMatch(challenge.Challenger, team, false) == false {
-			fmt.Println("Invalid match.")
+			round.trace("Invalid match.")
 		} else if round.validateMatch(challenge.Challenger, team, false) == true {
-			fmt.Println("Maximum rank opponent available.")
+			round.trace("Maximum rank opponent available.")
 			round.takeTeam(challenge.Challenger, team, challenge)
 			break
 		} else if i == challengerRank+1 {
-			fmt.Println("No valid match for", challenge.Challenger)
+			round.trace("No valid match for", challenge.Challenger)
 			challenge.ValidMatch = false
 		}
 	}
 }
 
-func (round *Round) generateChallenges(manualAssignLeftover bool) {
-	challenges := make(map[string]*Challenge)
-	teams := round.Teams
-	prefs := round.Prefs
-	descSortedTeams := round.DescOrder
+func (round *Round) challengeAny(challenge *Challenge) {
 	ascSortedTeams := round.AscOrder
-	newTeams := round.NewTeams
-	var deferredTeams []string
+	round.trace("Checking opponents for", challenge.Challenger)
+
+	for i := round.lastRankAbove(challenge.ChallengerRank); i > 0; i-- {
+		team := ascSortedTeams[i]
+		if team == "" {
+			continue
+		}
+		round.trace("Checking if the following team is good:", team)
+		if round.validateMatch(challenge.Challenger, team, true) == false {
+			round.trace("Invalid match.")
+		} else {
+			round.takeTeam(challenge.Challenger, team, challenge)
+			break
+		}
+		if i == 1 {
+			round.trace("No valid match for", challenge.Challenger)
+			challenge.ValidMatch = false
+		}
+	}
+}
 
-	// Give challanges to new teams
+// AutoAssign gives each deferred challenger the lowest ranked team above it
+// that it can take.
+func (round *Round) AutoAssign(deferredTeams []string) {
+	teams := round.Teams
 
-	for _, challenger := range newTeams {
-		if challenger != "" && prefs[challenger] != nil && prefs[challenger].Challenge {
+	for _, challenger := range deferredTeams {
+		if challenger != "" {
 			var challenge Challenge
 			challenge.Challenger = challenger
 			challenge.ChallengerRank = teams[challenger].Rank
 			challenge.Round = round.Current
 
-			fmt.Println("Trying to give a match to", challenger)
-			pref := prefs[challenger]
-
-			if round.validateMatch(challenger, pref.First, true) {
-				fmt.Println("First preference available for", challenger)
-				round.takeTeam(challenger, pref.First, &challenge)
-			} else if round.validateMatch(challenger, pref.Second, true) {
-				fmt.Println("Second preference available for", challenger)
-				round.takeTeam(challenger, pref.Second, &challenge)
-			} else if round.validateMatch(challenger, pref.Third, true) {
-				fmt.Println("Third preference available for", challenger)
-				round.takeTeam(challenger, pref.Third, &challenge)
-			} else {
-				fmt.Println("No preference available, checking last resort for", challenger)
-				// Check for max or min
-				switch pref.LastResortPref {
-				case None:
-					challenge.ValidMatch = false
-					fmt.Println("No valid match for ", challenge.Challenger)
-				case MinRank:
-					// Get the available challengeable team with minimum rank
-					fmt.Println("Min rank opponent preferred.")
-					round.challengeMinRank(&challenge)
-				case MaxRank:
-					fmt.Println("Max rank opponent preferred.")
-					round.challengeMaxRank(&challenge)
-					// Get the available challengeable team with maximum rank
-				case Any:
-					fmt.Println("Willing to challenge anyone.")
-					deferredTeams = append(deferredTeams, challenger)
-				}
-			}
-
+			round.challengeAny(&challenge)
 			if challenge.ValidMatch == true {
-				challenges[challenger] = &challenge
+				round.Chals[challenger] = &challenge
 			}
 		}
 	}
+}
 
+func (round *Round) releaseTeam(defender string) {
+	teams := round.Teams
 
-	// Give challenges to teams based on priorities
+	if teams[defender].Rank == 1 && teams[defender].TakenTwo {
+		teams[defender].TakenTwo = false
+	} else {
+		teams[defender].Taken = false
+	}
+}
 
-	for _, challenger := range descSortedTeams {
-		if challenger != "" && prefs[challenger] != nil && prefs[challenger].Challenge {
-			var challenge Challenge
-			challenge.Challenger = challenger
-			challenge.ChallengerRank = teams[challenger].Rank
-			challenge.Round = round.Current
+// Try the challenger's preferences in order, then its last resort. Teams
+// willing to challenge anyone are reported as deferred instead.
+func (round *Round) resolvePreferences(challenger string, ignoreMac bool) (*Challenge, bool) {
+	teams := round.Teams
+	prefs := round.Prefs
 
-			fmt.Println("Trying to give a match to", challenger)
-			pref := prefs[challenger]
-
-			if round.validateMatch(challenger, pref.First, false) {
-				fmt.Println("First preference available for", challenger)
-				round.takeTeam(challenger, pref.First, &challenge)
-			} else if round.validateMatch(challenger, pref.Second, false) {
-				fmt.Println("Second preference available for", challenger)
-				round.takeTeam(challenger, pref.Second, &challenge)
-			} else if round.validateMatch(challenger, pref.Third, false) {
-				fmt.Println("Third preference available for", challenger)
-				round.takeTeam(challenger, pref.Third, &challenge)
-			} else {
-				fmt.Println("No preference available, checking last resort for", challenger)
-				// Check for max or min
-				switch pref.LastResortPref {
-				case None:
-					challenge.ValidMatch = false
-					fmt.Println("No valid match for ", challenge.Challenger)
-				case MinRank:
-					// Get the available challengeable team with minimum rank
-					fmt.Println("Min rank opponent preferred.")
-					round.challengeMinRank(&challenge)
-				case MaxRank:
-					fmt.Println("Max rank opponent preferred.")
-					round.challengeMaxRank(&challenge)
-					// Get the available challengeable team with maximum rank
-				case Any:
-					fmt.Println("Willing to challenge anyone.")
-					deferredTeams = append(deferredTeams, challenger)
-				}
-			}
+	var challenge Challenge
+	challenge.Challenger = challenger
+	challenge.ChallengerRank = teams[challenger].Rank
+	challenge.Round = round.Current
 
-			if challenge.ValidMatch == true {
-				challenges[challenger] = &challenge
-			}
+	round.trace("Trying to give a match to", challenger)
+	pref := prefs[challenger]
+
+	for i, pick := range pref.Picks {
+		if round.validateMatch(challenger, pick, ignoreMac) {
+			round.trace("Preference", i+1, "available for", challenger)
+			round.takeTeam(challenger, pick, &challenge)
+			return &challenge, false
 		}
 	}
 
-	// Give challenges to deferred teams
+	round.trace("No preference available, checking last resort for", challenger)
+	// Check for max or min
+	switch pref.LastResortPref {
+	case None:
+		challenge.ValidMatch = false
+		round.trace("No valid match for ", challenge.Challenger)
+	case MinRank:
+		// Get the available challengeable team with minimum rank
+		round.trace("Min rank opponent preferred.")
+		round.challengeMinRank(&challenge)
+	case MaxRank:
+		round.trace("Max rank opponent preferred.")
+		round.challengeMaxRank(&challenge)
+		// Get the available challengeable team with maximum rank
+	case Any:
+		round.trace("Willing to challenge anyone.")
+		return &challenge, true
+	}
 
-	if !manualAssignLeftover {
-		// Auto-assignment for deferred teams
-		for _, challenger := range deferredTeams {
-			if challenger != "" {
-				fmt.Println("Checking opponents for", challenger)
-				var challenge Challenge
-				challenge.Challenger = challenger
-				challenge.ChallengerRank = teams[challenger].Rank
-				challenge.Round = round.Current
-
-				for i := challenge.ChallengerRank - 1; i > 0; i-- {
-					team := ascSortedTeams[i]
-					fmt.Println("Checking if the following team is good:", team)
-					if round.validateMatch(challenger, team, true) == false {
-						fmt.Println("Invalid match.")
-					} else {
-						round.takeTeam(challenger, team, &challenge)
-						break
-					}
-					if i == 1 {
-						fmt.Println("No valid match for", challenger)
-						challenge.ValidMatch = false
-					}
-				}
-				if challenge.ValidMatch == true {
-					challenges[challenger] = &challenge
-				}
+	return &challenge, false
+}
+
+func (round *Round) generateChallenges() {
+	round.Chals = make(map[string]*Challenge)
+	round.Rejections = make(map[string][]Rejection)
+	challenges := round.Chals
+	prefs := round.Prefs
+	ascSortedTeams := round.AscOrder
+	var deferredTeams []string
+
+	// Give challenges to teams based on priorities, new teams first
+
+	for _, challenger := range round.PriorityOrder() {
+		if prefs[challenger] != nil && prefs[challenger].Challenge {
+			challenge, deferred := round.resolvePreferences(challenger, round.Teams[challenger].New)
+			if deferred {
+				deferredTeams = append(deferredTeams, challenger)
+			} else if challenge.ValidMatch == true {
+				challenges[challenger] = challenge
 			}
 		}
-	} else {
+	}
+
+	// Give challenges to deferred teams
+
+	if round.replay {
+		// Replay recorded manual picks for deferred teams
+		round.replayManualPicks(deferredTeams)
+	} else if round.manualAssign != nil {
 		// Manual assign for deferred teams
-		for _, challenger := range deferredTeams {
-			fmt.Println("Manual assign needed for: ", challenger, "@", teams[challenger].Rank)
-			for team := range teams {
-				if round.Teams[team].Taken == false {
-					fmt.Println(team, " is not taken @", round.Teams[team].Rank)
-				}
-				if round.Teams[team].Rank == 1 && round.Teams[team].TakenTwo == false {
-					fmt.Println(team, " is not taken @", round.Teams[team].Rank)
-				}
-			}
-			var challenge Challenge
-			challenge.Challenger = challenger
-			challenge.ChallengerRank = teams[challenger].Rank
-			challenge.Round = round.Current
-			fmt.Println("Choose team rank to assign for ", challenger, "@", teams[challenger].Rank)
-			var i int
-			fmt.Scanf("%d", &i)
-			team := ascSortedTeams[i]
-			if round.validateMatch(challenger, team, true) == false {
-				fmt.Println("Invalid match.")
-			} else {
-				round.takeTeam(challenger, team, &challenge)
-			}
-			if challenge.ValidMatch == true {
-				challenges[challenger] = &challenge
-			}
-		}
+		round.manualAssign(round, deferredTeams)
+	} else {
+		// Auto-assignment for deferred teams
+		round.AutoAssign(deferredTeams)
 	}
 
 	// Give MatchCodes accordingly
@@ -459,42 +544,4 @@ func (round *Round) generateChallenges(manualAssignLeftover bool) {
 			}
 		}
 	}
-
-	round.Chals = challenges
-}
-
-func (round *Round) printChallenges() {
-	fmt.Println("==== ラウンド", round.Current, "全試合 ====")
-	for _, challenger := range round.AscOrder {
-		challenge := round.Chals[challenger]
-		if challenge != nil && challenge.ValidMatch {
-			if round.Teams[challenger].New {
-				fmt.Printf("[%d-%02d] New! %s vs %02d位 %s\n", challenge.Round, challenge.MatchCode, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
-			} else {
-				fmt.Printf("[%d-%02d] %02d位 %s vs %02d位 %s\n", challenge.Round, challenge.MatchCode, challenge.ChallengerRank, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
-			}
-		}
-	}
-	fmt.Println("==== ラウンド", round.Current, "全試合csv ====")
-	fmt.Println("id,挑戦側rank,挑戦側チーム名,防衛側rank,防衛側チーム名")
-	for _, challenger := range round.AscOrder {
-		challenge := round.Chals[challenger]
-		if challenge != nil && challenge.ValidMatch {
-			if round.Teams[challenger].New {
-				fmt.Printf("[%d-%02d],New,%s,%02d,%s\n", challenge.Round, challenge.MatchCode, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
-			} else {
-				fmt.Printf("[%d-%02d],%02d位,%s,%02d位,%s\n", challenge.Round, challenge.MatchCode, challenge.ChallengerRank, challenge.Challenger, challenge.DefenderRank, challenge.Defender)
-			}
-		}
-	}
-}
-
-func main() {
-	var round Round
-	currentRound := flag.Int("round", 0, "Current round")
-	manualAssignLeftover := flag.Bool("manual", false, "Manually assign leftovers")
-	flag.Parse()
-	round.initRound(*currentRound)
-	round.generateChallenges(*manualAssignLeftover)
-	round.printChallenges()
 }
@@ -0,0 +1,98 @@
+package ladder
+
+import (
+	"fmt"
+	"time"
+)
+
+// A LatePolicy decides what happens to preferences submitted after the
+// round's deadline.
+type LatePolicy int
+
+const (
+	// LateReject ignores late preferences, as if nothing was submitted.
+	LateReject LatePolicy = iota
+	// LateLowestPriority resolves late challengers after every punctual one.
+	LateLowestPriority
+	// LateAcceptOnly makes late teams accept challenges without making any.
+	LateAcceptOnly
+)
+
+func (p LatePolicy) String() string {
+	switch p {
+	case LateReject:
+		return "reject"
+	case LateLowestPriority:
+		return "lowest-priority"
+	case LateAcceptOnly:
+		return "accept-only"
+	}
+	return fmt.Sprintf("LatePolicy(%d)", int(p))
+}
+
+// ParseLatePolicy is the inverse of LatePolicy.String.
+func ParseLatePolicy(value string) (LatePolicy, error) {
+	for _, p := range []LatePolicy{LateReject, LateLowestPriority, LateAcceptOnly} {
+		if value == p.String() {
+			return p, nil
+		}
+	}
+	return LateReject, Errorf(ErrParse, "parse late policy", "unknown late policy %q", value)
+}
+
+func (p LatePolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *LatePolicy) UnmarshalText(text []byte) error {
+	value, err := ParseLatePolicy(string(text))
+	if err != nil {
+		return err
+	}
+	*p = value
+	return nil
+}
+
+// A LateEntry is a preference submitted after the deadline and the policy
+// applied to it.
+type LateEntry struct {
+	Team        string     `json:"team"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	Policy      LatePolicy `json:"policy"`
+}
+
+// Late preferences are only known when both the deadline and the submission
+// time are.
+func (round *Round) isLate(pref ProcessedPreference) bool {
+	return !round.Deadline.IsZero() && pref.SubmittedAt != nil && pref.SubmittedAt.After(round.Deadline)
+}
+
+func (round *Round) lateTeam(team string) bool {
+	for _, entry := range round.Late {
+		if entry.Team == team {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply the late policy to a preference as it is loaded. It returns false if
+// the preference is to be ignored.
+func (round *Round) applyLatePolicy(pref *ProcessedPreference) bool {
+	if !round.isLate(*pref) {
+		return true
+	}
+	round.Late = append(round.Late, LateEntry{Team: pref.Team, SubmittedAt: *pref.SubmittedAt, Policy: round.LatePolicy})
+	switch round.LatePolicy {
+	case LateReject:
+		round.trace("Ignoring the preferences of", pref.Team, "submitted after the deadline.")
+		return false
+	case LateLowestPriority:
+		round.trace(pref.Team, "submitted after the deadline and challenges last.")
+	case LateAcceptOnly:
+		round.trace(pref.Team, "submitted after the deadline and only accepts challenges.")
+		pref.Accept = true
+		pref.Challenge = false
+	}
+	return true
+}
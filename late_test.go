@@ -0,0 +1,44 @@
+package ladder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPriorityOrderLatePolicy(t *testing.T) {
+	deadline := time.Date(2024, 5, 1, 21, 0, 0, 0, time.UTC)
+	onTime, late := deadline.Add(-time.Hour), deadline.Add(time.Hour)
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 2, Name: "B", Division: "X"},
+		{Rank: 3, Name: "C", Division: "X"},
+		{Rank: 4, Name: "D", Division: "X"},
+		{Name: "N", Division: "X", New: true},
+	}
+	prefs := []RawPreference{
+		{Team: "A", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "B", Accept: AnswerAccept, Challenge: AnswerChallenge, SubmittedAt: &late},
+		{Team: "C", Accept: AnswerAccept, Challenge: AnswerChallenge, SubmittedAt: &onTime},
+		{Team: "D", Accept: AnswerAccept, Challenge: AnswerChallenge, SubmittedAt: &late},
+		{Team: "N", Accept: AnswerAccept, Challenge: AnswerChallenge},
+	}
+
+	tests := []struct {
+		policy LatePolicy
+		want   []string
+	}{
+		{LateReject, []string{"N", "D", "C", "B", "A"}},
+		{LateLowestPriority, []string{"N", "C", "A", "D", "B"}},
+		{LateAcceptOnly, []string{"N", "D", "C", "B", "A"}},
+	}
+	for _, test := range tests {
+		round, err := NewRound(teams, prefs, Options{Round: 1, Deadline: deadline, LatePolicy: test.policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := round.PriorityOrder(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: PriorityOrder() = %v, want %v", test.policy, got, test.want)
+		}
+	}
+}
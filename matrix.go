@@ -0,0 +1,163 @@
+package ladder
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+)
+
+// Why a cell of the eligibility matrix is blocked.
+const (
+	BlockNone          = ""
+	BlockSelf          = "self"
+	BlockRank          = "rank"
+	BlockMAC           = "mac"
+	BlockForbidden     = "forbidden"
+	BlockSharedPlayers = "shared_players"
+	BlockCooldown      = "cooldown"
+)
+
+// A MatrixCell is whether a challenger may challenge a defender under the
+// ladder rules alone.
+type MatrixCell struct {
+	Block  string
+	Reason string
+}
+
+// An EligibilityMatrix is the challenge graph of a round before any
+// preferences or taken flags are applied. Cells[i][j] is Teams[i]
+// challenging Teams[j].
+type EligibilityMatrix struct {
+	Round int
+	Teams []string
+	Ranks []int
+	Cells [][]MatrixCell
+}
+
+// EligibilityMatrix checks every pair of teams against the rank, MAC and
+// pairing rules, ignoring acceptance, previous opponents and taken slots.
+func (round *Round) EligibilityMatrix() EligibilityMatrix {
+	matrix := EligibilityMatrix{Round: round.Current}
+	for _, name := range round.AscOrder {
+		if name != "" {
+			matrix.Teams = append(matrix.Teams, name)
+			matrix.Ranks = append(matrix.Ranks, round.Teams[name].Rank)
+		}
+	}
+	for _, challenger := range matrix.Teams {
+		row := make([]MatrixCell, len(matrix.Teams))
+		for j, defender := range matrix.Teams {
+			row[j] = round.matrixCell(challenger, defender)
+		}
+		matrix.Cells = append(matrix.Cells, row)
+	}
+	return matrix
+}
+
+func (round *Round) matrixCell(challenger string, defender string) MatrixCell {
+	if challenger == defender {
+		return MatrixCell{BlockSelf, fmt.Sprint(challenger, " cannot challenge itself.")}
+	}
+	if reason := round.ruleRejection(challenger, defender); reason != "" {
+		if round.forbiddenReason(challenger, defender) != "" {
+			return MatrixCell{BlockForbidden, reason}
+		}
+		if round.cooldownRejection(challenger, defender) == reason {
+			return MatrixCell{BlockCooldown, reason}
+		}
+		return MatrixCell{BlockSharedPlayers, reason}
+	}
+	if reason := round.rankRejection(challenger, defender, round.Teams[challenger].New); reason != "" {
+		if round.Teams[challenger].Rank < round.Teams[defender].Rank {
+			return MatrixCell{BlockRank, reason}
+		}
+		return MatrixCell{BlockMAC, reason}
+	}
+	return MatrixCell{}
+}
+
+// WriteCSV writes the matrix with a row per challenger and a column per
+// defender. Eligible cells are "OK"; the others hold the blocking reason.
+func (matrix EligibilityMatrix) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"挑戦側\\防衛側"}
+	for i, team := range matrix.Teams {
+		header = append(header, fmt.Sprintf("%02d位 %s", matrix.Ranks[i], team))
+	}
+	writer.Write(header)
+	for i, team := range matrix.Teams {
+		record := []string{fmt.Sprintf("%02d位 %s", matrix.Ranks[i], team)}
+		for _, cell := range matrix.Cells[i] {
+			if cell.Block == BlockNone {
+				record = append(record, "OK")
+			} else {
+				record = append(record, cell.Block+": "+cell.Reason)
+			}
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+var blockColors = []struct {
+	block string
+	color string
+	label string
+}{
+	{BlockNone, "#66bb6a", "eligible"},
+	{BlockMAC, "#ef9a9a", "MAC"},
+	{BlockRank, "#eeeeee", "rank"},
+	{BlockForbidden, "#ce93d8", "forbidden"},
+	{BlockSharedPlayers, "#ffcc80", "shared players"},
+	{BlockCooldown, "#90caf9", "cooldown"},
+	{BlockSelf, "#9e9e9e", "self"},
+}
+
+// WriteSVG draws the matrix as a heatmap coloured by blocking reason. Each
+// cell carries its reason as a tooltip.
+func (matrix EligibilityMatrix) WriteSVG(w io.Writer) error {
+	const cell = 16
+	const label = 160
+	n := len(matrix.Teams)
+	width := label + n*cell + 1
+	height := label + n*cell + (len(blockColors)+1)*cell
+
+	colors := make(map[string]string)
+	for _, b := range blockColors {
+		colors[b.block] = b.color
+	}
+
+	var err error
+	printf := func(format string, a ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, a...)
+		}
+	}
+	printf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"sans-serif\" font-size=\"11\">\n", width, height)
+	printf("<title>ラウンド %d 挑戦可能マトリクス</title>\n", matrix.Round)
+	for i, team := range matrix.Teams {
+		name := html.EscapeString(fmt.Sprintf("%02d位 %s", matrix.Ranks[i], team))
+		y := label + i*cell
+		printf("<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%s</text>\n", label-4, y+cell-4, name)
+		printf("<text transform=\"translate(%d %d) rotate(-90)\">%s</text>\n", label+i*cell+cell-4, label-4, name)
+	}
+	for i, challenger := range matrix.Teams {
+		for j, c := range matrix.Cells[i] {
+			title := fmt.Sprint(challenger, " → ", matrix.Teams[j], ": OK")
+			if c.Block != BlockNone {
+				title = fmt.Sprint(challenger, " → ", matrix.Teams[j], ": ", c.Reason)
+			}
+			printf("<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"#fff\"><title>%s</title></rect>\n",
+				label+j*cell, label+i*cell, cell, cell, colors[c.Block], html.EscapeString(title))
+		}
+	}
+	for k, b := range blockColors {
+		y := label + n*cell + cell/2 + k*cell
+		printf("<rect x=\"4\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", y, cell-4, cell-4, b.color)
+		printf("<text x=\"%d\" y=\"%d\">%s</text>\n", cell+4, y+cell-5, b.label)
+	}
+	printf("</svg>\n")
+	return err
+}
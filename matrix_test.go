@@ -0,0 +1,81 @@
+package ladder
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestEligibilityMatrix(t *testing.T) {
+	sub := Player{ID: "sub"}
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "X", MAC: 2},
+		{Rank: 2, Name: "B", Division: "X", MAC: 5, Roster: []Player{{ID: "b"}, sub}},
+		{Rank: 3, Name: "C", Division: "X", MAC: 5},
+		{Rank: 4, Name: "D", Division: "X", MAC: 5},
+		{Rank: 5, Name: "E", Division: "X", MAC: 5, Roster: []Player{{ID: "e"}, sub}},
+	}
+	opts := Options{
+		Round:              2,
+		Forbidden:          []ForbiddenPair{{Team: "C", Opponent: "D", Reason: "dispute"}},
+		AvoidSharedPlayers: true,
+		Cooldowns:          []CooldownRule{Cooldown{Kind: CooldownRematch}},
+		History:            History{{Round: 1, Challenger: "D", Defender: "B", DefenderDivision: "X", Winner: "B"}},
+	}
+	round, err := NewRound(teams, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matrix := round.EligibilityMatrix()
+	if want := []string{"A", "B", "C", "D", "E"}; len(matrix.Teams) != len(want) {
+		t.Fatalf("Teams = %v, want %v", matrix.Teams, want)
+	}
+	index := make(map[string]int)
+	for i, team := range matrix.Teams {
+		index[team] = i
+	}
+
+	tests := []struct {
+		challenger string
+		defender   string
+		block      string
+	}{
+		{"A", "A", BlockSelf},
+		{"B", "A", BlockNone},
+		{"A", "B", BlockRank},
+		{"C", "A", BlockMAC},
+		{"C", "B", BlockNone},
+		{"D", "C", BlockForbidden},
+		{"C", "D", BlockForbidden},
+		{"E", "B", BlockSharedPlayers},
+		{"D", "B", BlockCooldown},
+		{"E", "D", BlockNone},
+	}
+	for _, test := range tests {
+		cell := matrix.Cells[index[test.challenger]][index[test.defender]]
+		if cell.Block != test.block {
+			t.Errorf("%s vs %s blocked by %q (%s), want %q", test.challenger, test.defender, cell.Block, cell.Reason, test.block)
+		}
+		if (cell.Reason == "") != (test.block == BlockNone) {
+			t.Errorf("%s vs %s has reason %q with block %q", test.challenger, test.defender, cell.Reason, cell.Block)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := matrix.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 6 || len(records[0]) != 6 {
+		t.Fatalf("CSV is %dx%d, want 6x6", len(records), len(records[0]))
+	}
+	if got := records[index["B"]+1][index["A"]+1]; got != "OK" {
+		t.Errorf("CSV cell of B vs A = %q, want OK", got)
+	}
+	if got := records[index["A"]+1][index["A"]+1]; got != BlockSelf+": "+matrix.Cells[0][0].Reason {
+		t.Errorf("CSV cell of A vs A = %q", got)
+	}
+}
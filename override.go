@@ -0,0 +1,328 @@
+package ladder
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// A roundState is a copy of everything an override can change, so that a
+// rejected override leaves the round untouched.
+type roundState struct {
+	taken      map[string][2]bool
+	chals      map[string]Challenge
+	prefs      map[string]ProcessedPreference
+	rejections map[string][]Rejection
+}
+
+func (round *Round) saveState() roundState {
+	state := roundState{
+		taken: make(map[string][2]bool),
+		chals: make(map[string]Challenge),
+		prefs: make(map[string]ProcessedPreference),
+	}
+	for name, team := range round.Teams {
+		state.taken[name] = [2]bool{team.Taken, team.TakenTwo}
+	}
+	for challenger, challenge := range round.Chals {
+		state.chals[challenger] = *challenge
+	}
+	for team, pref := range round.Prefs {
+		state.prefs[team] = *pref
+	}
+	if round.Rejections != nil {
+		state.rejections = make(map[string][]Rejection)
+	}
+	for challenger, rejections := range round.Rejections {
+		state.rejections[challenger] = append([]Rejection(nil), rejections...)
+	}
+	return state
+}
+
+func (round *Round) restoreState(state roundState) {
+	for name, taken := range state.taken {
+		round.Teams[name].Taken = taken[0]
+		round.Teams[name].TakenTwo = taken[1]
+	}
+	round.Chals = make(map[string]*Challenge)
+	for challenger, challenge := range state.chals {
+		c := challenge
+		round.Chals[challenger] = &c
+	}
+	round.Prefs = make(map[string]*ProcessedPreference)
+	for team, pref := range state.prefs {
+		p := pref
+		round.Prefs[team] = &p
+	}
+	round.Rejections = state.rejections
+}
+
+// ApplyOverride applies a single pin, assign, swap, remove or withdraw command, re-resolving any
+// challenger that lost its opponent and issuing codes only to matches that did
+// not have one. A rejected override leaves the round untouched.
+func (round *Round) ApplyOverride(line string) error {
+	op := fmt.Sprintf("override %q", line)
+	args, err := parseCommand(line)
+	if err != nil {
+		return WrapError(ErrParse, op, err)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	teams := make([]string, len(args)-1)
+	for i, arg := range args[1:] {
+		if teams[i], err = round.LookupTeam(arg); err != nil {
+			return WrapError(ErrValidation, op, err)
+		}
+	}
+
+	state := round.saveState()
+	switch strings.ToLower(args[0]) {
+	case "pin":
+		if len(teams) != 2 {
+			return Errorf(ErrParse, op, "usage: pin <challenger> <defender>")
+		}
+		err = round.pinChallenge(teams[0], teams[1])
+	case "swap":
+		if len(teams) != 2 {
+			return Errorf(ErrParse, op, "usage: swap <challenger> <challenger>")
+		}
+		err = round.swapChallenges(teams[0], teams[1])
+	case "assign":
+		if len(teams) != 2 {
+			return Errorf(ErrParse, op, "usage: assign <challenger> <defender>")
+		}
+		err = round.assignChallenge(teams[0], teams[1])
+	case "remove":
+		if len(teams) != 1 {
+			return Errorf(ErrParse, op, "usage: remove <challenger>")
+		}
+		err = round.removeChallenge(teams[0])
+	case "withdraw":
+		if len(teams) != 1 {
+			return Errorf(ErrParse, op, "usage: withdraw <team>")
+		}
+		err = round.withdrawTeam(teams[0])
+	default:
+		return Errorf(ErrParse, op, "unknown command %q", args[0])
+	}
+	if err != nil {
+		round.restoreState(state)
+		return WrapError(ErrValidation, op, err)
+	}
+
+	round.reissueMatchCodes()
+	round.Overrides = append(round.Overrides, line)
+	return nil
+}
+
+// Commands are space separated; team names containing spaces can be quoted.
+func parseCommand(line string) ([]string, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimSpace(line)))
+	reader.Comma = ' '
+	fields, err := reader.Read()
+	if err != nil {
+		if strings.TrimSpace(line) == "" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var args []string
+	for _, field := range fields {
+		if field != "" {
+			args = append(args, field)
+		}
+	}
+	return args, nil
+}
+
+// Unassign detaches the challenger from its current opponent and returns the
+// dropped challenge, if any.
+func (round *Round) Unassign(challenger string) *Challenge {
+	challenge := round.Chals[challenger]
+	if challenge == nil {
+		return nil
+	}
+	if challenge.ValidMatch {
+		round.releaseTeam(challenge.Defender)
+	}
+	delete(round.Chals, challenger)
+	return challenge
+}
+
+func (round *Round) pinChallenge(challenger string, defender string) error {
+	teams := round.Teams
+	previous := round.Unassign(challenger)
+
+	// Free a slot on the defender by displacing a challenger that was not pinned.
+	var displaced []*Challenge
+	if round.CheckTaken(defender) && round.Prefs[defender] != nil && round.Prefs[defender].Accept {
+		for _, other := range round.AscOrder {
+			challenge := round.Chals[other]
+			if other != "" && challenge != nil && challenge.Defender == defender && !challenge.Pinned {
+				displaced = append(displaced, round.Unassign(other))
+				break
+			}
+		}
+	}
+
+	if reason := round.MatchRejection(challenger, defender, teams[challenger].New); reason != "" {
+		return fmt.Errorf("cannot pin %s vs %s: %s", challenger, defender, reason)
+	}
+
+	var challenge Challenge
+	challenge.Challenger = challenger
+	challenge.ChallengerRank = teams[challenger].Rank
+	challenge.Round = round.Current
+	challenge.Pinned = true
+	if previous != nil {
+		challenge.MatchCode = previous.MatchCode
+	}
+	round.takeTeam(challenger, defender, &challenge)
+	round.Chals[challenger] = &challenge
+
+	for _, lost := range displaced {
+		round.trace(lost.Challenger, "lost", defender, "and is re-resolved.")
+		round.reresolveChallenger(lost)
+	}
+	return nil
+}
+
+func (round *Round) swapChallenges(first string, second string) error {
+	a := round.Unassign(first)
+	b := round.Unassign(second)
+	if a == nil || !a.ValidMatch {
+		return fmt.Errorf("%s has no match to swap", first)
+	}
+	if b == nil || !b.ValidMatch {
+		return fmt.Errorf("%s has no match to swap", second)
+	}
+
+	for _, pair := range [][2]*Challenge{{a, b}, {b, a}} {
+		challenger, defender := pair[0].Challenger, pair[1].Defender
+		if reason := round.MatchRejection(challenger, defender, round.Teams[challenger].New); reason != "" {
+			return fmt.Errorf("cannot swap: %s vs %s: %s", challenger, defender, reason)
+		}
+		challenge := *pair[0]
+		challenge.Pinned = true
+		round.takeTeam(challenger, defender, &challenge)
+		round.Chals[challenger] = &challenge
+	}
+	return nil
+}
+
+// Give an unmatched challenger an opponent the way a manual assignment does,
+// ignoring the defender's MAC.
+func (round *Round) assignChallenge(challenger string, defender string) error {
+	if challenge := round.Chals[challenger]; challenge != nil && challenge.ValidMatch {
+		return fmt.Errorf("%s already plays %s", challenger, challenge.Defender)
+	}
+	challenge, err := round.Assign(challenger, defender)
+	if err != nil {
+		return err
+	}
+	challenge.Pinned = true
+	return nil
+}
+
+func (round *Round) removeChallenge(challenger string) error {
+	if round.Unassign(challenger) == nil {
+		return fmt.Errorf("%s has no match to remove", challenger)
+	}
+	round.trace("Removed the match for", challenger)
+	return nil
+}
+
+// Take a team out of a resolved round. Its own match is dropped, the
+// challengers it was defending against are re-resolved, and every other
+// match keeps its opponent and code. With Refill, challengers left without a
+// match also get another try at the freed defenders.
+func (round *Round) withdrawTeam(team string) error {
+	pref := round.Prefs[team]
+	if pref != nil && !pref.Accept && !pref.Challenge {
+		return fmt.Errorf("%s is neither accepting nor making challenges", team)
+	}
+	if pref == nil {
+		pref = &ProcessedPreference{Team: team}
+		round.Prefs[team] = pref
+	}
+	pref.Accept = false
+	pref.Challenge = false
+
+	if round.Unassign(team) != nil {
+		round.trace("Removed the match for", team)
+	}
+	var lost []*Challenge
+	for _, challenger := range round.PriorityOrder() {
+		if challenge := round.Chals[challenger]; challenge != nil && challenge.Defender == team {
+			lost = append(lost, round.Unassign(challenger))
+		}
+	}
+	for _, challenge := range lost {
+		round.trace(challenge.Challenger, "lost", team, "and is re-resolved.")
+		round.reresolveChallenger(challenge)
+	}
+
+	if !round.Refill {
+		return nil
+	}
+	for _, challenger := range round.PriorityOrder() {
+		pref := round.Prefs[challenger]
+		if pref == nil || !pref.Challenge || round.Chals[challenger] != nil {
+			continue
+		}
+		challenge, deferred := round.resolvePreferences(challenger, round.Teams[challenger].New)
+		if deferred {
+			round.challengeAny(challenge)
+		}
+		if challenge.ValidMatch {
+			round.trace(challenger, "takes a slot freed by", team)
+			round.Chals[challenger] = challenge
+		}
+	}
+	return nil
+}
+
+// Run the resolver again for a challenger whose opponent was taken away,
+// keeping its match code if it finds a new opponent.
+func (round *Round) reresolveChallenger(previous *Challenge) {
+	challenger := previous.Challenger
+	prefs := round.Prefs
+	if prefs[challenger] == nil || !prefs[challenger].Challenge {
+		return
+	}
+
+	challenge, deferred := round.resolvePreferences(challenger, round.Teams[challenger].New)
+	if deferred {
+		round.challengeAny(challenge)
+	}
+	if challenge.ValidMatch {
+		challenge.MatchCode = previous.MatchCode
+		round.Chals[challenger] = challenge
+	}
+}
+
+// Give the lowest free codes to matches without one, leaving the codes of
+// every other match untouched.
+func (round *Round) reissueMatchCodes() {
+	used := make(map[int]bool)
+	for _, challenge := range round.Chals {
+		if challenge.ValidMatch && challenge.MatchCode > 0 {
+			used[challenge.MatchCode] = true
+		}
+	}
+
+	code := 1
+	for _, challenger := range round.AscOrder {
+		challenge := round.Chals[challenger]
+		if challenger == "" || challenge == nil || !challenge.ValidMatch || challenge.MatchCode > 0 {
+			continue
+		}
+		for used[code] {
+			code++
+		}
+		challenge.MatchCode = code
+		used[code] = true
+	}
+}
@@ -0,0 +1,163 @@
+package ladder
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestReissueMatchCodes(t *testing.T) {
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 2, Name: "B", Division: "X"},
+		{Rank: 3, Name: "C", Division: "X"},
+		{Rank: 4, Name: "D", Division: "X"},
+		{Rank: 5, Name: "E", Division: "X"},
+		{Rank: 6, Name: "F", Division: "X"},
+	}
+	round, err := NewRound(teams, nil, Options{Round: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	round.Chals = map[string]*Challenge{
+		"B": {ValidMatch: true, Defender: "A", MatchCode: 2},
+		"C": {ValidMatch: true, Defender: "A"},
+		"D": {ValidMatch: true, Defender: "B", MatchCode: 4},
+		"E": {Defender: "C"},
+		"F": {ValidMatch: true, Defender: "D"},
+	}
+	round.reissueMatchCodes()
+
+	want := map[string]int{"B": 2, "C": 1, "D": 4, "E": 0, "F": 3}
+	for challenger, code := range want {
+		if got := round.Chals[challenger].MatchCode; got != code {
+			t.Errorf("match code of %s = %d, want %d", challenger, got, code)
+		}
+	}
+}
+
+// overrideFixture resolves a round of six teams to C vs A, D vs B, E vs C and
+// F vs D, with codes 1 to 4 in that order.
+func overrideFixture(t *testing.T, opts Options) *Round {
+	t.Helper()
+	var teams []Team
+	for i, name := range []string{"A", "B", "C", "D", "E", "F"} {
+		teams = append(teams, Team{Rank: i + 1, Name: name, Division: "A"})
+	}
+	prefs := []RawPreference{
+		{Team: "A", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "B", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "C", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"B", "A"}},
+		{Team: "D", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"B", "A"}},
+		{Team: "E", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"C"}, LastResortPref: AnswerMinRank},
+		{Team: "F", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"D"}},
+	}
+	opts.Round = 1
+	result, err := Resolve(teams, prefs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Round
+}
+
+// matches maps every challenger with a valid match to its defender and code.
+func matches(round *Round) map[string]string {
+	got := make(map[string]string)
+	for challenger, challenge := range round.Chals {
+		if challenge.ValidMatch {
+			got[challenger] = fmt.Sprintf("%s %d", challenge.Defender, challenge.MatchCode)
+		}
+	}
+	return got
+}
+
+func TestApplyOverride(t *testing.T) {
+	resolved := map[string]string{"C": "A 1", "D": "B 2", "E": "C 3", "F": "D 4"}
+	tests := []struct {
+		name  string
+		lines []string
+		want  map[string]string
+		err   bool
+	}{
+		{"pin displaces", []string{"pin F C"},
+			map[string]string{"C": "A 1", "D": "B 2", "E": "D 3", "F": "C 4"}, false},
+		{"pin keeps pinned", []string{"pin F C", "pin D C"},
+			map[string]string{"C": "A 1", "D": "B 2", "E": "D 3", "F": "C 4"}, true},
+		{"swap", []string{"swap E F"},
+			map[string]string{"C": "A 1", "D": "B 2", "E": "D 3", "F": "C 4"}, false},
+		{"rejected swap", []string{"swap F C"}, resolved, true},
+		{"remove", []string{"remove D"},
+			map[string]string{"C": "A 1", "E": "C 3", "F": "D 4"}, false},
+		{"assign", []string{"remove D", "assign D A"},
+			map[string]string{"C": "A 1", "D": "A 2", "E": "C 3", "F": "D 4"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			round := overrideFixture(t, Options{})
+			var err error
+			for _, line := range test.lines {
+				before := round.saveState()
+				if err = round.ApplyOverride(line); err != nil {
+					if !reflect.DeepEqual(round.saveState(), before) {
+						t.Errorf("%q was rejected but changed the round", line)
+					}
+					break
+				}
+			}
+			if (err != nil) != test.err {
+				t.Errorf("error %v, want an error %v", err, test.err)
+			}
+			if got := matches(round); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("matches %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithdrawTeam(t *testing.T) {
+	var teams []Team
+	for i, name := range []string{"A", "B", "C", "D", "E"} {
+		teams = append(teams, Team{Rank: i + 1, Name: name, Division: "A"})
+	}
+	// E vs C and D vs B, while C finds B taken.
+	prefs := []RawPreference{
+		{Team: "A", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "B", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "C", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"B"}},
+		{Team: "D", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"B", "A"}},
+		{Team: "E", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"C"}},
+	}
+	tests := []struct {
+		name   string
+		team   string
+		refill bool
+		want   map[string]string
+	}{
+		{"challenger", "D", false, map[string]string{"E": "C 2"}},
+		{"defender falls back to the next pick", "B", false, map[string]string{"D": "A 1", "E": "C 2"}},
+		{"defender without another pick", "C", false, map[string]string{"D": "B 1"}},
+		{"refill takes the freed slot", "D", true, map[string]string{"C": "B 1", "E": "C 2"}},
+		{"refill without a free pick", "B", true, map[string]string{"D": "A 1", "E": "C 2"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Resolve(teams, prefs, Options{Round: 1, Refill: test.refill})
+			if err != nil {
+				t.Fatal(err)
+			}
+			round := result.Round
+			if got, want := matches(round), map[string]string{"D": "B 1", "E": "C 2"}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("resolved to %v, want %v", got, want)
+			}
+			if err := round.ApplyOverride("withdraw " + test.team); err != nil {
+				t.Fatal(err)
+			}
+			if got := matches(round); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("matches %v, want %v", got, test.want)
+			}
+			if err := round.ApplyOverride("withdraw " + test.team); err == nil {
+				t.Errorf("withdrawing %s twice succeeded", test.team)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package ladder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PatchPreferences applies what-if commands to the preferences the round was
+// resolved from and returns the patched form answers. The round itself is
+// left untouched. The commands are:
+//
+//	accept <team> yes|no
+//	challenge <team> yes|no
+//	pick <team> <n> <defender>|-
+//	first|second|third <team> <defender>|-
+//	picks <team> [<defender> ...]
+//	last-resort <team> none|min_rank|max_rank|any
+//	withdraw <team>
+//
+// A withdrawn team keeps its rank but neither accepts nor makes challenges.
+func (round *Round) PatchPreferences(lines []string) ([]RawPreference, error) {
+	patched := make(map[string]*ProcessedPreference)
+	for _, line := range lines {
+		if err := round.patchPreference(patched, line); err != nil {
+			return nil, err
+		}
+	}
+
+	var prefs []RawPreference
+	for _, raw := range round.RawPrefs {
+		if pref := patched[raw.Team]; pref != nil {
+			raw = pref.Raw()
+			delete(patched, raw.Team)
+		}
+		prefs = append(prefs, raw)
+	}
+	// Teams that had not submitted anything.
+	for _, team := range round.AscOrder {
+		if pref := patched[team]; team != "" && pref != nil {
+			prefs = append(prefs, pref.Raw())
+		}
+	}
+	return prefs, nil
+}
+
+func (round *Round) patchPreference(patched map[string]*ProcessedPreference, line string) error {
+	op := fmt.Sprintf("patch %q", line)
+	args, err := parseCommand(line)
+	if err != nil {
+		return WrapError(ErrParse, op, err)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	if len(args) < 2 {
+		return Errorf(ErrParse, op, "usage: %s <team> ...", args[0])
+	}
+
+	team, err := round.LookupTeam(args[1])
+	if err != nil {
+		return WrapError(ErrValidation, op, err)
+	}
+	pref := patched[team]
+	if pref == nil {
+		pref = &ProcessedPreference{Team: team}
+		if round.Prefs[team] != nil {
+			*pref = *round.Prefs[team]
+		}
+		patched[team] = pref
+	}
+
+	command := strings.ToLower(args[0])
+	switch command {
+	case "accept", "challenge":
+		if len(args) != 3 {
+			return Errorf(ErrParse, op, "usage: %s <team> yes|no", command)
+		}
+		value, ok := map[string]bool{"yes": true, "no": false}[strings.ToLower(args[2])]
+		if !ok {
+			return Errorf(ErrParse, op, "expected yes or no, got %q", args[2])
+		}
+		if command == "accept" {
+			pref.Accept = value
+		} else {
+			pref.Challenge = value
+		}
+	case "pick", "first", "second", "third":
+		var n int
+		switch command {
+		case "pick":
+			if len(args) != 4 {
+				return Errorf(ErrParse, op, "usage: pick <team> <n> <defender>|-")
+			}
+			if _, err := fmt.Sscan(args[2], &n); err != nil || n < 1 {
+				return Errorf(ErrParse, op, "expected a pick number, got %q", args[2])
+			}
+			args = append(args[:2], args[3])
+		case "first":
+			n = 1
+		case "second":
+			n = 2
+		case "third":
+			n = 3
+		}
+		if len(args) != 3 {
+			return Errorf(ErrParse, op, "usage: %s <team> <defender>|-", command)
+		}
+		defender := ""
+		if args[2] != "-" {
+			if defender, err = round.LookupTeam(args[2]); err != nil {
+				return WrapError(ErrValidation, op, err)
+			}
+		}
+		pref.Picks = setPick(pref.Picks, n, defender)
+	case "picks":
+		var picks []string
+		for _, arg := range args[2:] {
+			defender, err := round.LookupTeam(arg)
+			if err != nil {
+				return WrapError(ErrValidation, op, err)
+			}
+			picks = append(picks, defender)
+		}
+		pref.Picks = picks
+	case "last-resort":
+		if len(args) != 3 {
+			return Errorf(ErrParse, op, "usage: last-resort <team> none|min_rank|max_rank|any")
+		}
+		lastResort, err := ParseLastResort(args[2])
+		if err != nil {
+			return WrapError(ErrParse, op, err)
+		}
+		pref.LastResortPref = lastResort
+	case "withdraw":
+		if len(args) != 2 {
+			return Errorf(ErrParse, op, "usage: withdraw <team>")
+		}
+		pref.Accept = false
+		pref.Challenge = false
+	default:
+		return Errorf(ErrParse, op, "unknown command %q", args[0])
+	}
+	return nil
+}
+
+// setPick replaces the nth pick, starting at 1, with defender, appending it
+// if there are fewer picks, or removes it if defender is empty. The picks are
+// copied, as they may be shared with the round.
+func setPick(picks []string, n int, defender string) []string {
+	patched := append([]string(nil), picks...)
+	switch {
+	case defender == "" && n <= len(patched):
+		return append(patched[:n-1], patched[n:]...)
+	case defender == "":
+		return patched
+	case n <= len(patched):
+		patched[n-1] = defender
+		return patched
+	}
+	return append(patched, defender)
+}
+
+// Kinds of ChallengeChange.
+const (
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+	ChangeDefender  = "defender"
+	ChangeMatchCode = "match_code"
+)
+
+// A ChallengeChange is a difference between two resolutions of a round for a
+// single challenger. Before is nil for added matches and After for removed
+// ones.
+type ChallengeChange struct {
+	Kind       string
+	Challenger string
+	Before     *Challenge
+	After      *Challenge
+}
+
+// DiffChallenges compares the valid matches of two resolutions, ordered by
+// the challenger's rank. A new defender is reported as ChangeDefender even if
+// the match code changed too.
+func DiffChallenges(before map[string]*Challenge, after map[string]*Challenge) []ChallengeChange {
+	var changes []ChallengeChange
+	valid := func(challenges map[string]*Challenge, challenger string) *Challenge {
+		if challenge := challenges[challenger]; challenge != nil && challenge.ValidMatch {
+			return challenge
+		}
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, challenges := range []map[string]*Challenge{before, after} {
+		for challenger := range challenges {
+			if seen[challenger] {
+				continue
+			}
+			seen[challenger] = true
+
+			b, a := valid(before, challenger), valid(after, challenger)
+			change := ChallengeChange{Challenger: challenger, Before: b, After: a}
+			switch {
+			case b == nil && a == nil:
+				continue
+			case b == nil:
+				change.Kind = ChangeAdded
+			case a == nil:
+				change.Kind = ChangeRemoved
+			case b.Defender != a.Defender:
+				change.Kind = ChangeDefender
+			case b.MatchCode != a.MatchCode:
+				change.Kind = ChangeMatchCode
+			default:
+				continue
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	rank := func(change ChallengeChange) int {
+		if change.Before != nil {
+			return change.Before.ChallengerRank
+		}
+		return change.After.ChallengerRank
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if rank(changes[i]) != rank(changes[j]) {
+			return rank(changes[i]) < rank(changes[j])
+		}
+		return changes[i].Challenger < changes[j].Challenger
+	})
+	return changes
+}
@@ -0,0 +1,135 @@
+package ladder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetPick(t *testing.T) {
+	tests := []struct {
+		picks    []string
+		n        int
+		defender string
+		want     []string
+	}{
+		{nil, 1, "A", []string{"A"}},
+		{[]string{"A", "B"}, 1, "C", []string{"C", "B"}},
+		{[]string{"A", "B"}, 2, "C", []string{"A", "C"}},
+		{[]string{"A", "B"}, 3, "C", []string{"A", "B", "C"}},
+		{[]string{"A", "B"}, 5, "C", []string{"A", "B", "C"}},
+		{[]string{"A", "B", "C"}, 2, "", []string{"A", "C"}},
+		{[]string{"A", "B"}, 3, "", []string{"A", "B"}},
+	}
+	for _, test := range tests {
+		picks := append([]string(nil), test.picks...)
+		got := setPick(picks, test.n, test.defender)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("setPick(%v, %d, %q) = %v, want %v", test.picks, test.n, test.defender, got, test.want)
+		}
+		if !reflect.DeepEqual(picks, test.picks) {
+			t.Errorf("setPick(%v, %d, %q) changed its input to %v", test.picks, test.n, test.defender, picks)
+		}
+	}
+}
+
+func TestPatchPreferences(t *testing.T) {
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 2, Name: "B", Division: "X"},
+		{Rank: 3, Name: "C", Division: "X"},
+		{Rank: 4, Name: "D", Division: "X"},
+	}
+	prefs := []RawPreference{
+		{Team: "A", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "D", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"A", "B"}},
+	}
+	round, err := NewRound(teams, prefs, Options{Round: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		team  string
+		want  RawPreference
+		err   bool
+	}{
+		{"pick", []string{"pick D 2 C"}, "D",
+			RawPreference{Team: "D", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"A", "C"}}, false},
+		{"second by rank", []string{"second D 3"}, "D",
+			RawPreference{Team: "D", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"A", "C"}}, false},
+		{"remove first", []string{"first D -"}, "D",
+			RawPreference{Team: "D", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"B"}}, false},
+		{"picks", []string{"picks D C B A"}, "D",
+			RawPreference{Team: "D", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"C", "B", "A"}}, false},
+		{"new submission", []string{"accept C yes", "challenge C yes", "pick C 1 B"}, "C",
+			RawPreference{Team: "C", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"B"}}, false},
+		{"withdraw", []string{"withdraw D"}, "D",
+			RawPreference{Team: "D", Accept: AnswerDecline, Challenge: AnswerNoChallenge, Picks: []string{"A", "B"}}, false},
+		{"unknown defender", []string{"pick D 1 Z"}, "", RawPreference{}, true},
+		{"rank past the ladder", []string{"pick D 1 5"}, "", RawPreference{}, true},
+		{"bad pick number", []string{"pick D 0 A"}, "", RawPreference{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patched, err := round.PatchPreferences(test.lines)
+			if test.err {
+				if err == nil {
+					t.Fatalf("PatchPreferences(%q) succeeded, want an error", test.lines)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got *RawPreference
+			for i := range patched {
+				if patched[i].Team == test.team {
+					got = &patched[i]
+				}
+			}
+			if got == nil {
+				t.Fatalf("PatchPreferences(%q) has no preference for %s", test.lines, test.team)
+			}
+			got.LastResortPref = ""
+			if !reflect.DeepEqual(*got, test.want) {
+				t.Errorf("PatchPreferences(%q) = %+v, want %+v", test.lines, *got, test.want)
+			}
+		})
+	}
+	if picks := round.Prefs["D"].Picks; !reflect.DeepEqual(picks, []string{"A", "B"}) {
+		t.Errorf("the round's picks of D changed to %v", picks)
+	}
+}
+
+func TestDiffChallenges(t *testing.T) {
+	match := func(challenger string, rank int, defender string, code int) *Challenge {
+		return &Challenge{ValidMatch: true, Challenger: challenger, ChallengerRank: rank, Defender: defender, MatchCode: code}
+	}
+	before := map[string]*Challenge{
+		"B": match("B", 2, "A", 1),
+		"C": match("C", 3, "B", 2),
+		"D": match("D", 4, "C", 3),
+		"E": match("E", 5, "D", 4),
+		"F": {Challenger: "F", ChallengerRank: 6},
+	}
+	after := map[string]*Challenge{
+		"B": match("B", 2, "A", 1),
+		"C": match("C", 3, "A", 3),
+		"D": match("D", 4, "C", 2),
+		"F": match("F", 6, "E", 4),
+	}
+	want := []ChallengeChange{
+		{ChangeDefender, "C", before["C"], after["C"]},
+		{ChangeMatchCode, "D", before["D"], after["D"]},
+		{ChangeRemoved, "E", before["E"], nil},
+		{ChangeAdded, "F", nil, after["F"]},
+	}
+	if got := DiffChallenges(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffChallenges() = %+v, want %+v", got, want)
+	}
+	if got := DiffChallenges(before, before); len(got) != 0 {
+		t.Errorf("DiffChallenges() of a round with itself = %+v, want none", got)
+	}
+}
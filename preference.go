@@ -0,0 +1,194 @@
+package ladder
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxPicks is the number of picks the challenge form asks for.
+const DefaultMaxPicks = 3
+
+// Answers of the challenge form.
+const (
+	AnswerAccept      = "受け付ける"
+	AnswerDecline     = "受け付けない"
+	AnswerChallenge   = "行う"
+	AnswerNoChallenge = "行わない"
+	AnswerNone        = "どこにもチャレンジしない"
+	AnswerMinRank     = "チャレンジ可能な範囲で一番順位の低いチームにチャレンジする"
+	AnswerMaxRank     = "チャレンジ可能な範囲で一番順位の高いチームにチャレンジする"
+	AnswerAny         = "自分より上位のチームならどこでもいいからチャレンジする"
+)
+
+func (l LastResortChallenge) String() string {
+	switch l {
+	case None:
+		return "none"
+	case MinRank:
+		return "min_rank"
+	case MaxRank:
+		return "max_rank"
+	case Any:
+		return "any"
+	}
+	return fmt.Sprintf("LastResortChallenge(%d)", int(l))
+}
+
+// Answer returns the form answer that selects l.
+func (l LastResortChallenge) Answer() string {
+	switch l {
+	case MinRank:
+		return AnswerMinRank
+	case MaxRank:
+		return AnswerMaxRank
+	case Any:
+		return AnswerAny
+	}
+	return AnswerNone
+}
+
+// ParseLastResort is the inverse of LastResortChallenge.String.
+func ParseLastResort(value string) (LastResortChallenge, error) {
+	for _, l := range []LastResortChallenge{None, MinRank, MaxRank, Any} {
+		if value == l.String() {
+			return l, nil
+		}
+	}
+	return None, Errorf(ErrParse, "parse last resort", "unknown last resort %q", value)
+}
+
+func (l LastResortChallenge) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *LastResortChallenge) UnmarshalText(text []byte) error {
+	value, err := ParseLastResort(string(text))
+	if err != nil {
+		return err
+	}
+	*l = value
+	return nil
+}
+
+// ParsePreference converts the answers of the challenge form into a
+// ProcessedPreference. Unrecognized answers are left at their zero value.
+func ParsePreference(rawPref RawPreference) ProcessedPreference {
+	var pref ProcessedPreference
+
+	pref.Team = rawPref.Team
+	pref.PrevChallenged = rawPref.PrevChallenged
+	pref.Picks = rawPref.Picks
+	pref.SubmittedAt = rawPref.SubmittedAt
+
+	switch rawPref.Accept {
+	case AnswerAccept:
+		pref.Accept = true
+	case AnswerDecline:
+		pref.Accept = false
+	}
+
+	switch rawPref.Challenge {
+	case AnswerChallenge:
+		pref.Challenge = true
+	case AnswerNoChallenge:
+		pref.Challenge = false
+	}
+
+	switch rawPref.LastResortPref {
+	case AnswerNone:
+		pref.LastResortPref = None
+	case AnswerMinRank:
+		pref.LastResortPref = MinRank
+	case AnswerMaxRank:
+		pref.LastResortPref = MaxRank
+	case AnswerAny:
+		pref.LastResortPref = Any
+	}
+
+	return pref
+}
+
+// Raw returns the form answers that parse back into pref, so that preferences
+// submitted without the form go through the same pipeline.
+func (pref ProcessedPreference) Raw() RawPreference {
+	raw := RawPreference{
+		Team:           pref.Team,
+		Accept:         AnswerDecline,
+		Challenge:      AnswerNoChallenge,
+		PrevChallenged: pref.PrevChallenged,
+		Picks:          pref.Picks,
+		SubmittedAt:    pref.SubmittedAt,
+	}
+	if pref.Accept {
+		raw.Accept = AnswerAccept
+	}
+	if pref.Challenge {
+		raw.Challenge = AnswerChallenge
+	}
+	raw.LastResortPref = pref.LastResortPref.Answer()
+	return raw
+}
+
+// ParsePicks splits a free-text answer naming one or more teams, separated by
+// commas, semicolons or new lines, into a list of picks. If isTeam is not
+// nil, the longest run of fields naming a team is kept whole, so that names
+// holding a separator are not split.
+func ParsePicks(value string, isTeam func(string) bool) []string {
+	var fields [][2]int
+	start := 0
+	for i, r := range value {
+		if r == ',' || r == '、' || r == ';' || r == '\n' {
+			fields = append(fields, [2]int{start, i})
+			start = i + utf8.RuneLen(r)
+		}
+	}
+	fields = append(fields, [2]int{start, len(value)})
+
+	var picks []string
+	for i := 0; i < len(fields); i++ {
+		pick := strings.TrimSpace(value[fields[i][0]:fields[i][1]])
+		for j := len(fields) - 1; j > i && isTeam != nil; j-- {
+			if name := strings.TrimSpace(value[fields[i][0]:fields[j][1]]); isTeam(name) {
+				pick, i = name, j
+				break
+			}
+		}
+		if pick != "" {
+			picks = append(picks, pick)
+		}
+	}
+	return picks
+}
+
+// TrimPicks trims answers naming a single team each and skips the blank ones.
+func TrimPicks(values ...string) []string {
+	var picks []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			picks = append(picks, value)
+		}
+	}
+	return picks
+}
+
+// UnmarshalJSON also reads preferences saved before picks were a list, with
+// the fields first, second and third.
+func (raw *RawPreference) UnmarshalJSON(b []byte) error {
+	type rawPreference RawPreference
+	var v struct {
+		rawPreference
+		First  string `json:"first"`
+		Second string `json:"second"`
+		Third  string `json:"third"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*raw = RawPreference(v.rawPreference)
+	if raw.Picks == nil {
+		raw.Picks = TrimPicks(v.First, v.Second, v.Third)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package ladder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePicks(t *testing.T) {
+	known := map[string]bool{"A": true, "B": true, "Smith, Jones": true, "1、2": true}
+	isTeam := func(name string) bool { return known[name] }
+	tests := []struct {
+		value  string
+		isTeam func(string) bool
+		want   []string
+	}{
+		{"", isTeam, nil},
+		{"A", isTeam, []string{"A"}},
+		{"A, B", isTeam, []string{"A", "B"}},
+		{"A、B;\nC", isTeam, []string{"A", "B", "C"}},
+		{" A ,, B ", isTeam, []string{"A", "B"}},
+		{"Smith, Jones", isTeam, []string{"Smith, Jones"}},
+		{"A, Smith, Jones, B", isTeam, []string{"A", "Smith, Jones", "B"}},
+		{"1、2; A", isTeam, []string{"1、2", "A"}},
+		{"Smith, Jones", nil, []string{"Smith", "Jones"}},
+	}
+	for _, test := range tests {
+		if got := ParsePicks(test.value, test.isTeam); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParsePicks(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
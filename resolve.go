@@ -0,0 +1,281 @@
+package ladder
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options configures Resolve.
+type Options struct {
+	// Round is the number of the round being resolved.
+	Round int
+	// Seed decides every random choice, e.g. the priority of new teams.
+	Seed int64
+
+	Forbidden          []ForbiddenPair
+	AvoidSharedPlayers bool
+
+	// ManualAssign, if set, is called with the teams willing to challenge
+	// anyone instead of assigning them automatically.
+	ManualAssign func(round *Round, deferredTeams []string)
+	// ManualPicks replays the picks of an earlier manual assignment.
+	ManualPicks []ManualPick
+	// Overrides are applied in order once every challenger is resolved.
+	Overrides []string
+	// Deadline, if set, marks the preferences submitted after it as late,
+	// to be handled by LatePolicy.
+	Deadline   time.Time
+	LatePolicy LatePolicy
+	// DefaultPreference is taken by the teams that submitted no preferences.
+	// Only its Accept, Challenge and LastResortPref are used. If nil, those
+	// teams neither accept nor make challenges.
+	DefaultPreference *ProcessedPreference
+	// PrevOpponents maps teams to their previous opponent, so that they
+	// cannot challenge it again. It fills in the preferences that name none,
+	// such as those taking DefaultPreference or submitted on a page that does
+	// not know the previous round.
+	PrevOpponents map[string]string
+
+	// MaxPicks, if positive, ignores the picks of a team after the first
+	// MaxPicks.
+	MaxPicks int
+
+	// Cooldowns rule out pairings from the matches in History. Only the
+	// built-in Cooldown rules are saved by Round.Record.
+	Cooldowns []CooldownRule
+	History   History
+
+	// Refill lets challengers without a match take the defenders freed by a
+	// withdraw override, instead of only re-resolving the ones that lost
+	// their opponent.
+	Refill bool
+
+	// Results are the reported winners of the round's matches, kept when it
+	// is recorded again.
+	Results map[string]string
+
+	// Log receives a trace of the resolution. Nothing is written if nil.
+	Log io.Writer
+}
+
+// A Result is a resolved round.
+type Result struct {
+	Round      *Round
+	Challenges []*Challenge
+	Rejections map[string][]Rejection
+	Conflicts  []RosterConflict
+	Late       []LateEntry
+	Defaulted  []string
+}
+
+// DivisionMAC returns the lowest rank that can challenge a team of the given
+// division and rank.
+func DivisionMAC(division string, rank int) int {
+	switch division {
+	case "X":
+		return rank + 2
+	case "S+":
+		return rank + 3
+	case "S":
+		return rank + 4
+	case "A+":
+		return rank + 5
+	case "A":
+		return MaxParticipants
+	}
+	return 0
+}
+
+// NewRound checks the inputs and sets up a round ready to be resolved. The
+// teams are copied, so the caller's values are never modified.
+func NewRound(teams []Team, prefs []RawPreference, opts Options) (*Round, error) {
+	teamMap := make(map[string]*Team)
+	ranks := make(map[int]string)
+	// The ranked teams must hold exactly the ranks 1 to their number.
+	ranked := 0
+	for _, team := range teams {
+		if !team.New {
+			ranked++
+		}
+	}
+	for _, team := range teams {
+		t := team
+		if t.Name == "" {
+			return nil, Errorf(ErrValidation, "new round", "team with rank %d has no name", t.Rank)
+		}
+		if teamMap[t.Name] != nil {
+			return nil, Errorf(ErrValidation, "new round", "team %s is listed twice", t.Name)
+		}
+		if !t.New {
+			if t.Rank < 1 || t.Rank > ranked {
+				return nil, Errorf(ErrValidation, "new round", "team %s has rank %d outside 1-%d", t.Name, t.Rank, ranked)
+			}
+			if other, ok := ranks[t.Rank]; ok {
+				return nil, Errorf(ErrValidation, "new round", "teams %s and %s share rank %d", other, t.Name, t.Rank)
+			}
+			ranks[t.Rank] = t.Name
+		}
+		if t.MAC == 0 {
+			t.MAC = DivisionMAC(t.Division, t.Rank)
+		}
+		t.Taken = false
+		t.TakenTwo = false
+		teamMap[t.Name] = &t
+	}
+	for _, pref := range prefs {
+		if teamMap[pref.Team] == nil {
+			return nil, Errorf(ErrValidation, "new round", "preferences submitted for unknown team %s", pref.Team)
+		}
+	}
+
+	round := &Round{
+		Forbidden:          opts.Forbidden,
+		AvoidSharedPlayers: opts.AvoidSharedPlayers,
+		Seed:               opts.Seed,
+		ManualPicks:        opts.ManualPicks,
+		Refill:             opts.Refill,
+		MaxPicks:           opts.MaxPicks,
+		Cooldowns:          opts.Cooldowns,
+		History:            opts.History,
+		Deadline:           opts.Deadline,
+		LatePolicy:         opts.LatePolicy,
+		DefaultPreference:  opts.DefaultPreference,
+		PrevOpponents:      opts.PrevOpponents,
+		Results:            opts.Results,
+		Log:                opts.Log,
+		manualAssign:       opts.ManualAssign,
+		replay:             opts.ManualPicks != nil,
+	}
+	round.setupRound(teamMap, prefs, opts.Round)
+	return round, nil
+}
+
+// Resolve gives every challenger an opponent according to its preferences
+// and returns the resulting matches. It performs no I/O other than writing
+// to opts.Log and calling opts.ManualAssign.
+func Resolve(teams []Team, prefs []RawPreference, opts Options) (Result, error) {
+	round, err := NewRound(teams, prefs, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	round.generateChallenges()
+	for _, line := range opts.Overrides {
+		if err := round.ApplyOverride(line); err != nil {
+			return Result{}, err
+		}
+	}
+	return round.Result(), nil
+}
+
+// Result summarizes the round's current matches, ordered by match code.
+func (round *Round) Result() Result {
+	var challenges []*Challenge
+	for _, challenge := range round.Chals {
+		if challenge.ValidMatch {
+			challenges = append(challenges, challenge)
+		}
+	}
+	sort.Slice(challenges, func(i, j int) bool {
+		return challenges[i].MatchCode < challenges[j].MatchCode
+	})
+	return Result{
+		Round:      round,
+		Challenges: challenges,
+		Rejections: round.Rejections,
+		Conflicts:  round.RosterConflicts(),
+		Late:       round.Late,
+		Defaulted:  round.Defaulted,
+	}
+}
+
+// Assign matches a deferred challenger with defender. Like the automatic
+// assignment of deferred teams, it ignores the defender's MAC.
+func (round *Round) Assign(challenger string, defender string) (*Challenge, error) {
+	if reason := round.MatchRejection(challenger, defender, true); reason != "" {
+		return nil, Errorf(ErrValidation, "assign", "%s", reason)
+	}
+	var challenge Challenge
+	challenge.Challenger = challenger
+	challenge.ChallengerRank = round.Teams[challenger].Rank
+	challenge.Round = round.Current
+	round.takeTeam(challenger, defender, &challenge)
+	round.Chals[challenger] = &challenge
+	return &challenge, nil
+}
+
+// Available lists, in ladder order, the teams the challenger could be
+// assigned right now.
+func (round *Round) Available(challenger string) []string {
+	var available []string
+	for _, team := range round.AscOrder {
+		if team != "" && team != challenger && round.MatchRejection(challenger, team, true) == "" {
+			available = append(available, team)
+		}
+	}
+	return available
+}
+
+// Eligible lists, in ladder order, the teams that pass every check of
+// StaticRejection for the challenger, i.e. the picks that can succeed this
+// round unless another challenger takes the defender first.
+func (round *Round) Eligible(challenger string) []string {
+	var eligible []string
+	for _, team := range round.AscOrder {
+		if team != "" && team != challenger && round.StaticRejection(challenger, team) == "" {
+			eligible = append(eligible, team)
+		}
+	}
+	return eligible
+}
+
+// Challengeable lists, in ladder order, the teams the challenger may name as
+// a preference under the rank and MAC rules, regardless of whether they are
+// accepting or taken this round.
+func (round *Round) Challengeable(challenger string) []string {
+	teams := round.Teams
+	info := teams[challenger]
+	if info == nil {
+		return nil
+	}
+	var challengeable []string
+	for _, team := range round.AscOrder {
+		if team == "" || team == challenger || teams[team].New {
+			continue
+		}
+		if teams[team].Rank > info.Rank {
+			continue
+		}
+		if !info.New && teams[team].MAC < info.Rank {
+			continue
+		}
+		challengeable = append(challengeable, team)
+	}
+	return challengeable
+}
+
+// LookupTeam resolves user input to a team name. A team named exactly as the
+// input wins, so that teams named with digits only can be given by name;
+// otherwise digits, optionally prefixed with "#", are the rank of a ranked
+// team, and anything else is matched by name ignoring case.
+func (round *Round) LookupTeam(input string) (string, error) {
+	if round.Teams[input] != nil {
+		return input, nil
+	}
+	if rank, err := strconv.Atoi(strings.TrimPrefix(input, "#")); err == nil {
+		// The new teams follow the ranked slots.
+		if rank < 1 || rank >= len(round.AscOrder)-len(round.NewTeams) || round.AscOrder[rank] == "" {
+			return "", fmt.Errorf("no team at rank %d", rank)
+		}
+		return round.AscOrder[rank], nil
+	}
+	for _, team := range round.AscOrder {
+		if team != "" && strings.EqualFold(team, input) {
+			return team, nil
+		}
+	}
+	return "", fmt.Errorf("no team matches %q", input)
+}
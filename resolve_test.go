@@ -0,0 +1,85 @@
+package ladder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookupTeam(t *testing.T) {
+	round, err := NewRound([]Team{
+		{Rank: 1, Name: "Alpha", Division: "X"},
+		{Rank: 2, Name: "Beta", Division: "X"},
+		{Rank: 3, Name: "1", Division: "X"},
+		{Name: "Gamma", Division: "X", New: true},
+	}, nil, Options{Round: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		input string
+		want  string
+		err   string
+	}{
+		{"1", "1", ""},
+		{"#1", "Alpha", ""},
+		{"2", "Beta", ""},
+		{"3", "1", ""},
+		{"#3", "1", ""},
+		{"4", "", "no team at rank 4"},
+		{"5", "", "no team at rank 5"},
+		{"0", "", "no team at rank 0"},
+		{"-1", "", "no team at rank -1"},
+		{"Gamma", "Gamma", ""},
+		{"beta", "Beta", ""},
+		{"Delta", "", `no team matches "Delta"`},
+	}
+	for _, test := range tests {
+		got, err := round.LookupTeam(test.input)
+		if got != test.want {
+			t.Errorf("LookupTeam(%q) = %q, want %q", test.input, got, test.want)
+		}
+		if (err == nil) != (test.err == "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("LookupTeam(%q) error = %v, want %q", test.input, err, test.err)
+		}
+	}
+}
+
+func TestNewRoundRejectsRankGaps(t *testing.T) {
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 3, Name: "C", Division: "A"},
+		{Name: "N", Division: "A", New: true},
+	}
+	prefs := []RawPreference{
+		{Team: "A", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "C", Accept: AnswerAccept, Challenge: AnswerChallenge, LastResortPref: AnswerMinRank},
+	}
+	if _, err := Resolve(teams, prefs, Options{Round: 1}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Resolve() error = %v, want a validation error", err)
+	}
+}
+
+func TestLastResortOfNewTeamPastTheRanks(t *testing.T) {
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "A"},
+		{Rank: 2, Name: "B", Division: "A"},
+		{Rank: 5, Name: "N", Division: "A", New: true},
+		{Rank: 6, Name: "M", Division: "A", New: true},
+	}
+	for _, lastResort := range []string{AnswerMinRank, AnswerMaxRank, AnswerAny} {
+		prefs := []RawPreference{
+			{Team: "A", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+			{Team: "B", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+			{Team: "M", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+			{Team: "N", Accept: AnswerAccept, Challenge: AnswerChallenge, LastResortPref: lastResort},
+		}
+		result, err := Resolve(teams, prefs, Options{Round: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		challenge := result.Round.Chals["N"]
+		if challenge == nil || !challenge.ValidMatch || result.Round.Teams[challenge.Defender].New {
+			t.Errorf("last resort %q: N got %+v, want a ranked defender", lastResort, challenge)
+		}
+	}
+}
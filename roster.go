@@ -0,0 +1,102 @@
+package ladder
+
+import "fmt"
+
+// A Player is identified by either their ID or their friend code.
+type Player struct {
+	ID         string `json:"id"`
+	FriendCode string `json:"friend_code"`
+}
+
+func (p Player) String() string {
+	if p.ID == "" {
+		return p.FriendCode
+	}
+	return p.ID
+}
+
+func (p Player) samePlayer(other Player) bool {
+	return (p.ID != "" && p.ID == other.ID) ||
+		(p.FriendCode != "" && p.FriendCode == other.FriendCode)
+}
+
+// A RosterConflict is a player registered on two teams that are both
+// scheduled this round, either against each other or in separate matches.
+type RosterConflict struct {
+	Team      string
+	Other     string
+	Players   []Player
+	SameMatch bool
+}
+
+func (round *Round) sharedPlayers(team string, other string) []Player {
+	var shared []Player
+	for _, player := range round.Teams[team].Roster {
+		for _, otherPlayer := range round.Teams[other].Roster {
+			if player.samePlayer(otherPlayer) {
+				shared = append(shared, player)
+				break
+			}
+		}
+	}
+	return shared
+}
+
+// scheduledSharedPlayers returns why challenger and defender cannot play
+// while a team of another valid match shares players with either of them, or
+// an empty string.
+func (round *Round) scheduledSharedPlayers(challenger string, defender string) string {
+	for _, other := range round.AscOrder {
+		challenge := round.Chals[other]
+		if other == "" || other == challenger || challenge == nil || !challenge.ValidMatch {
+			continue
+		}
+		for _, scheduled := range []string{other, challenge.Defender} {
+			if scheduled == challenger || scheduled == defender {
+				continue
+			}
+			for _, team := range []string{challenger, defender} {
+				if shared := round.sharedPlayers(team, scheduled); len(shared) > 0 {
+					return fmt.Sprint(team, " shares players with ", scheduled, ", who plays ", other, " vs ", challenge.Defender, ": ", shared)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// RosterConflicts finds every pair of scheduled teams that share a player.
+func (round *Round) RosterConflicts() []RosterConflict {
+	var scheduled []string
+	seen := make(map[string]bool)
+	paired := make(map[[2]string]bool)
+	for _, challenger := range round.AscOrder {
+		challenge := round.Chals[challenger]
+		if challenger == "" || challenge == nil || !challenge.ValidMatch {
+			continue
+		}
+		for _, team := range []string{challenger, challenge.Defender} {
+			if !seen[team] {
+				seen[team] = true
+				scheduled = append(scheduled, team)
+			}
+		}
+		paired[[2]string{challenger, challenge.Defender}] = true
+		paired[[2]string{challenge.Defender, challenger}] = true
+	}
+
+	var conflicts []RosterConflict
+	for i, team := range scheduled {
+		for _, other := range scheduled[i+1:] {
+			if shared := round.sharedPlayers(team, other); len(shared) > 0 {
+				conflicts = append(conflicts, RosterConflict{
+					Team:      team,
+					Other:     other,
+					Players:   shared,
+					SameMatch: paired[[2]string{team, other}],
+				})
+			}
+		}
+	}
+	return conflicts
+}
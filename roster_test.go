@@ -0,0 +1,45 @@
+package ladder
+
+import "testing"
+
+func TestAvoidSharedPlayersAcrossMatches(t *testing.T) {
+	sub := Player{ID: "sub"}
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 2, Name: "B", Division: "X", Roster: []Player{{ID: "b"}, sub}},
+		{Rank: 3, Name: "C", Division: "X"},
+		{Rank: 4, Name: "D", Division: "X", Roster: []Player{{ID: "d"}, sub}},
+	}
+	prefs := []RawPreference{
+		{Team: "A", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "B", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"A"}},
+		{Team: "C", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "D", Accept: AnswerAccept, Challenge: AnswerChallenge, Picks: []string{"C"}},
+	}
+
+	tests := []struct {
+		avoid bool
+		want  int
+	}{
+		{false, 2},
+		{true, 1},
+	}
+	for _, test := range tests {
+		result, err := Resolve(teams, prefs, Options{Round: 1, AvoidSharedPlayers: test.avoid})
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches := 0
+		for _, challenge := range result.Round.Chals {
+			if challenge.ValidMatch {
+				matches++
+			}
+		}
+		if matches != test.want {
+			t.Errorf("avoid %v: %d matches, want %d", test.avoid, matches, test.want)
+		}
+		if conflicts := result.Round.RosterConflicts(); test.avoid && len(conflicts) > 0 {
+			t.Errorf("avoid %v: conflicts %v", test.avoid, conflicts)
+		}
+	}
+}
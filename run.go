@@ -0,0 +1,170 @@
+package ladder
+
+import (
+	"sort"
+	"time"
+)
+
+// A RunRecord holds everything a run was resolved from and its results, so
+// that it can be resolved again without the spreadsheet.
+type RunRecord struct {
+	Round              int                   `json:"round"`
+	Seed               int64                 `json:"seed"`
+	Teams              []Team                `json:"teams"`
+	Prefs              []RawPreference       `json:"prefs"`
+	Forbidden          []ForbiddenPair       `json:"forbidden"`
+	AvoidSharedPlayers bool                  `json:"avoid_shared_players"`
+	ManualPicks        []ManualPick          `json:"manual_picks"`
+	Overrides          []string              `json:"overrides"`
+	Refill             bool                  `json:"refill"`
+	Deadline           *time.Time            `json:"deadline,omitempty"`
+	LatePolicy         LatePolicy            `json:"late_policy"`
+	DefaultPreference  *ProcessedPreference  `json:"default_pref,omitempty"`
+	PrevOpponents      map[string]string     `json:"prev_opponents,omitempty"`
+	MaxPicks           int                   `json:"max_picks,omitempty"`
+	Cooldowns          []Cooldown            `json:"cooldowns,omitempty"`
+	History            History               `json:"history,omitempty"`
+	Challenges         map[string]*Challenge `json:"challenges"`
+	// Inactivity is each team's count of consecutive rounds without
+	// preferences after this round, for the teams of the next round.
+	Inactivity map[string]int `json:"inactivity"`
+	// Results maps challengers to the winners of their matches, as reported
+	// after the round was played.
+	Results map[string]string `json:"results,omitempty"`
+}
+
+// A ManualPick is an opponent chosen by hand for a deferred challenger. An
+// empty Defender means the challenger was skipped.
+type ManualPick struct {
+	Challenger string `json:"challenger"`
+	Defender   string `json:"defender"`
+}
+
+// Record captures the round's inputs and results.
+func (round *Round) Record() RunRecord {
+	var names []string
+	for name := range round.Teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var teams []Team
+	for _, name := range names {
+		team := *round.Teams[name]
+		// Taken flags are derived again from the preferences on replay.
+		team.Taken = false
+		team.TakenTwo = false
+		teams = append(teams, team)
+	}
+
+	var cooldowns []Cooldown
+	for _, rule := range round.Cooldowns {
+		if cooldown, ok := rule.(Cooldown); ok {
+			cooldowns = append(cooldowns, cooldown)
+		}
+	}
+
+	var deadline *time.Time
+	if !round.Deadline.IsZero() {
+		d := round.Deadline
+		deadline = &d
+	}
+
+	// Results of matches changed since they were reported are dropped.
+	var results map[string]string
+	for challenger, winner := range round.Results {
+		challenge := round.Chals[challenger]
+		if challenge == nil || !challenge.ValidMatch || (winner != challenger && winner != challenge.Defender) {
+			continue
+		}
+		if results == nil {
+			results = make(map[string]string)
+		}
+		results[challenger] = winner
+	}
+
+	return RunRecord{
+		Round:              round.Current,
+		Seed:               round.Seed,
+		Teams:              teams,
+		Prefs:              round.RawPrefs,
+		Forbidden:          round.Forbidden,
+		AvoidSharedPlayers: round.AvoidSharedPlayers,
+		ManualPicks:        round.ManualPicks,
+		Overrides:          round.Overrides,
+		Refill:             round.Refill,
+		Deadline:           deadline,
+		LatePolicy:         round.LatePolicy,
+		DefaultPreference:  round.DefaultPreference,
+		PrevOpponents:      round.PrevOpponents,
+		MaxPicks:           round.MaxPicks,
+		Cooldowns:          cooldowns,
+		History:            round.History,
+		Challenges:         round.Chals,
+		Inactivity:         round.Inactivity(),
+		Results:            results,
+	}
+}
+
+// PrevChallenged maps every challenger of the recorded round to its
+// opponent, to fill in the previous opponents of the next round.
+func (record RunRecord) PrevChallenged() map[string]string {
+	opponents := make(map[string]string)
+	for challenger, challenge := range record.Challenges {
+		if challenge.ValidMatch {
+			opponents[challenger] = challenge.Defender
+		}
+	}
+	return opponents
+}
+
+// Options returns the options that resolve the record's inputs again.
+func (record RunRecord) Options() Options {
+	var cooldowns []CooldownRule
+	for _, cooldown := range record.Cooldowns {
+		cooldowns = append(cooldowns, cooldown)
+	}
+	var deadline time.Time
+	if record.Deadline != nil {
+		deadline = *record.Deadline
+	}
+	return Options{
+		Round:              record.Round,
+		Seed:               record.Seed,
+		Forbidden:          record.Forbidden,
+		AvoidSharedPlayers: record.AvoidSharedPlayers,
+		ManualPicks:        record.ManualPicks,
+		Overrides:          record.Overrides,
+		Refill:             record.Refill,
+		Deadline:           deadline,
+		LatePolicy:         record.LatePolicy,
+		DefaultPreference:  record.DefaultPreference,
+		PrevOpponents:      record.PrevOpponents,
+		MaxPicks:           record.MaxPicks,
+		Cooldowns:          cooldowns,
+		History:            record.History,
+		Results:            record.Results,
+	}
+}
+
+// Apply recorded manual picks instead of prompting, auto-assigning whoever
+// was left when the picks were recorded.
+func (round *Round) replayManualPicks(deferredTeams []string) {
+	for i, challenger := range deferredTeams {
+		if i >= len(round.ManualPicks) {
+			round.AutoAssign(deferredTeams[i:])
+			return
+		}
+		pick := round.ManualPicks[i]
+		if pick.Challenger != challenger {
+			round.trace("Recorded pick for", pick.Challenger, "does not match deferred", challenger)
+			continue
+		}
+		if pick.Defender == "" {
+			continue
+		}
+		if _, err := round.Assign(challenger, pick.Defender); err != nil {
+			round.trace("Unable to replay pick for", challenger+":", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ladder
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestResolveIsReproducible(t *testing.T) {
+	teams := []Team{
+		{Rank: 1, Name: "A", Division: "A"},
+		{Rank: 2, Name: "B", Division: "A"},
+		{Rank: 3, Name: "C", Division: "A"},
+		{Name: "N1", Division: "A", New: true},
+		{Name: "N2", Division: "A", New: true},
+		{Name: "N3", Division: "A", New: true},
+	}
+	prefs := []RawPreference{
+		{Team: "A", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "B", Accept: AnswerAccept, Challenge: AnswerNoChallenge},
+		{Team: "C", Accept: AnswerAccept, Challenge: AnswerChallenge, LastResortPref: AnswerAny},
+		{Team: "N1", Accept: AnswerDecline, Challenge: AnswerChallenge, Picks: []string{"B"}, LastResortPref: AnswerAny},
+		{Team: "N2", Accept: AnswerDecline, Challenge: AnswerChallenge, Picks: []string{"B"}, LastResortPref: AnswerAny},
+		{Team: "N3", Accept: AnswerDecline, Challenge: AnswerChallenge, Picks: []string{"B"}, LastResortPref: AnswerAny},
+	}
+	opts := Options{Round: 1, Seed: 42, Overrides: []string{"remove C"}}
+
+	first, err := Resolve(teams, prefs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Resolve(teams, prefs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first.Round.Chals, second.Round.Chals) {
+		t.Errorf("the same seed resolved to %v and %v", matches(first.Round), matches(second.Round))
+	}
+
+	b, err := json.Marshal(first.Round.Record())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record RunRecord
+	if err := json.Unmarshal(b, &record); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := Resolve(record.Teams, record.Prefs, record.Options())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replayed.Round.Chals, record.Challenges) {
+		t.Errorf("the saved run resolved to %v, want %v", matches(replayed.Round), matches(first.Round))
+	}
+}
+
+func TestRecordKeepsResults(t *testing.T) {
+	record := overrideFixture(t, Options{}).Record()
+	for challenger, winner := range map[string]string{"C": "A", "F": "F"} {
+		if err := record.SetResult(challenger, winner); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := Resolve(record.Teams, record.Prefs, record.Options())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Round.Record().Results; !reflect.DeepEqual(got, record.Results) {
+		t.Errorf("results saved again = %v, want %v", got, record.Results)
+	}
+
+	// Withdrawing A takes away the match of C and its result.
+	if err := result.Round.ApplyOverride("withdraw A"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := result.Round.Record().Results, map[string]string{"F": "F"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("results after a withdrawal = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strconv"
+
+	"github.com/knagayama/ladder"
+)
+
+//go:embed static
+var static embed.FS
+
+func dashboardHandler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
+
+func parseRound(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	round, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, ladder.WrapError(ladder.ErrParse, "parse round", err)
+	}
+	return round, nil
+}
+
+// A LadderEntry is a team and its submitted preferences, as shown on the
+// dashboard.
+type LadderEntry struct {
+	Rank       int      `json:"rank"`
+	Team       string   `json:"team"`
+	Division   string   `json:"division"`
+	New        bool     `json:"new"`
+	Submitted  bool     `json:"submitted"`
+	Accept     bool     `json:"accept"`
+	Challenge  bool     `json:"challenge"`
+	Picks      []string `json:"picks"`
+	LastResort string   `json:"last_resort"`
+}
+
+// A RoundResponse is the body of GET /api/round. Teams and Prefs are the raw
+// inputs, to be posted back to /resolve unchanged.
+type RoundResponse struct {
+	Season string                 `json:"season"`
+	Round  int                    `json:"round"`
+	Ladder []LadderEntry          `json:"ladder"`
+	Teams  []ladder.Team          `json:"teams"`
+	Prefs  []ladder.RawPreference `json:"prefs"`
+}
+
+func handleRound(w http.ResponseWriter, r *http.Request, config Config) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	season := r.URL.Query().Get("season")
+	roundNumber, err := parseRound(r.URL.Query().Get("round"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	teams, prefs, err := config.LoadRound(season, roundNumber)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	round, err := ladder.NewRound(teams, prefs, config.options(roundNumber))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	resp := RoundResponse{
+		Season: season,
+		Round:  roundNumber,
+		Ladder: []LadderEntry{},
+		Teams:  teams,
+		Prefs:  prefs,
+	}
+	for _, name := range round.AscOrder {
+		if name == "" {
+			continue
+		}
+		team := round.Teams[name]
+		entry := LadderEntry{
+			Rank:     team.Rank,
+			Team:     name,
+			Division: team.Division,
+			New:      team.New,
+		}
+		if pref := round.Prefs[name]; pref != nil {
+			entry.Submitted = true
+			entry.Accept = pref.Accept
+			entry.Challenge = pref.Challenge
+			entry.Picks = pref.Picks
+			entry.LastResort = pref.LastResortPref.String()
+		}
+		resp.Ladder = append(resp.Ladder, entry)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/knagayama/ladder"
+)
+
+// An EligibleTeam is a defender the team may challenge this round.
+type EligibleTeam struct {
+	Rank     int    `json:"rank"`
+	Team     string `json:"team"`
+	Division string `json:"division"`
+}
+
+// A BlockedTeam is a higher ranked team the team may not challenge, and why.
+type BlockedTeam struct {
+	Rank   int    `json:"rank"`
+	Team   string `json:"team"`
+	Reason string `json:"reason"`
+}
+
+// An EligibleResponse is the body of GET /api/eligible.
+type EligibleResponse struct {
+	Season   string         `json:"season"`
+	Round    int            `json:"round"`
+	Team     string         `json:"team"`
+	Rank     int            `json:"rank"`
+	Eligible []EligibleTeam `json:"eligible"`
+	Blocked  []BlockedTeam  `json:"blocked"`
+}
+
+func handleEligible(w http.ResponseWriter, r *http.Request, config Config) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+
+	season := r.URL.Query().Get("season")
+	roundNumber, err := parseRound(r.URL.Query().Get("round"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	teams, prefs, err := config.LoadRound(season, roundNumber)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	round, err := ladder.NewRound(teams, prefs, config.options(roundNumber))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	name, err := round.LookupTeam(r.URL.Query().Get("team"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, ladder.WrapError(ladder.ErrValidation, "eligible", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, newEligibleResponse(round, season, name))
+}
+
+// A DemandResponse is the body of GET /api/demand.
+type DemandResponse struct {
+	Season  string          `json:"season"`
+	Round   int             `json:"round"`
+	Demands []ladder.Demand `json:"demands"`
+}
+
+func handleDemand(w http.ResponseWriter, r *http.Request, config Config) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+
+	season := r.URL.Query().Get("season")
+	roundNumber, err := parseRound(r.URL.Query().Get("round"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	var seed int64
+	if value := r.URL.Query().Get("seed"); value != "" {
+		if seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+			err = ladder.WrapError(ladder.ErrParse, "parse seed", err)
+			writeError(w, statusFor(err), err)
+			return
+		}
+	}
+	teams, prefs, err := config.LoadRound(season, roundNumber)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	opts := config.options(roundNumber)
+	opts.Seed = seed
+	result, err := ladder.Resolve(teams, prefs, opts)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	resp := DemandResponse{Season: season, Round: roundNumber, Demands: result.Round.Demand()}
+	if resp.Demands == nil {
+		resp.Demands = []ladder.Demand{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func newEligibleResponse(round *ladder.Round, season string, challenger string) EligibleResponse {
+	resp := EligibleResponse{
+		Season:   season,
+		Round:    round.Current,
+		Team:     challenger,
+		Rank:     round.Teams[challenger].Rank,
+		Eligible: []EligibleTeam{},
+		Blocked:  []BlockedTeam{},
+	}
+	for _, name := range round.AscOrder {
+		team := round.Teams[name]
+		if name == "" || name == challenger || team.Rank > resp.Rank {
+			continue
+		}
+		if reason := round.StaticRejection(challenger, name); reason != "" {
+			resp.Blocked = append(resp.Blocked, BlockedTeam{Rank: team.Rank, Team: name, Reason: reason})
+			continue
+		}
+		resp.Eligible = append(resp.Eligible, EligibleTeam{Rank: team.Rank, Team: name, Division: team.Division})
+	}
+	return resp
+}
@@ -0,0 +1,332 @@
+// Package server exposes the resolver over HTTP.
+//
+// POST /resolve takes the teams and preferences of a round, or a reference to
+// a season and round to load them from, and returns the resolved matches as
+// JSON. Every request is resolved on its own Round, so concurrent requests
+// for different leagues never share state.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/knagayama/ladder"
+)
+
+// maxRequestBytes bounds the size of a request body.
+const maxRequestBytes = 1 << 20
+
+// A Source loads the inputs of a round referenced by season and round.
+type Source func(season string, round int) ([]ladder.Team, []ladder.RawPreference, error)
+
+// A ResolveRequest is the body of POST /resolve. If Teams is empty, the
+// inputs are loaded from the server's Source using Season and Round.
+type ResolveRequest struct {
+	Season             string                 `json:"season"`
+	Round              int                    `json:"round"`
+	Seed               int64                  `json:"seed"`
+	Teams              []ladder.Team          `json:"teams"`
+	Prefs              []ladder.RawPreference `json:"prefs"`
+	Forbidden          []ladder.ForbiddenPair `json:"forbidden"`
+	AvoidSharedPlayers bool                   `json:"avoid_shared_players"`
+	Overrides          []string               `json:"overrides"`
+	Deadline           time.Time              `json:"deadline"`
+	LatePolicy         *ladder.LatePolicy     `json:"late_policy"`
+	// DefaultPreference is taken by the teams that submitted nothing.
+	DefaultPreference *ladder.ProcessedPreference `json:"default_pref"`
+	// Cooldowns are checked against the matches of History.
+	Cooldowns []ladder.Cooldown `json:"cooldowns"`
+	History   ladder.History    `json:"history"`
+}
+
+// A Match is a resolved challenge as returned by the API.
+type Match struct {
+	Code           string `json:"code"`
+	MatchCode      int    `json:"match_code"`
+	Challenger     string `json:"challenger"`
+	ChallengerRank int    `json:"challenger_rank"`
+	ChallengerNew  bool   `json:"challenger_new"`
+	Defender       string `json:"defender"`
+	DefenderRank   int    `json:"defender_rank"`
+	Pinned         bool   `json:"pinned"`
+}
+
+// A Conflict is a shared player between two scheduled teams.
+type Conflict struct {
+	Team      string          `json:"team"`
+	Other     string          `json:"other"`
+	Players   []ladder.Player `json:"players"`
+	SameMatch bool            `json:"same_match"`
+}
+
+// An Unmatched challenger wanted to challenge but has no opponent. Available
+// lists the teams it could still be assigned.
+type Unmatched struct {
+	Challenger string             `json:"challenger"`
+	Rank       int                `json:"rank"`
+	Rejections []ladder.Rejection `json:"rejections"`
+	Available  []string           `json:"available"`
+}
+
+// A ResolveResponse is the body of a successful POST /resolve.
+type ResolveResponse struct {
+	Round      int                           `json:"round"`
+	Matches    []Match                       `json:"matches"`
+	Unmatched  []Unmatched                   `json:"unmatched"`
+	Rejections map[string][]ladder.Rejection `json:"rejections"`
+	Conflicts  []Conflict                    `json:"conflicts"`
+	Late       []ladder.LateEntry            `json:"late"`
+	Defaulted  []string                      `json:"defaulted"`
+	Inactivity map[string]int                `json:"inactivity"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+	Kind  string `json:"kind,omitempty"`
+}
+
+// Config configures the handler returned by New.
+type Config struct {
+	// Source loads the teams and preferences of a round. It may be nil if
+	// every request carries its own teams and preferences.
+	Source Source
+	// Store keeps the preferences submitted on the submission page. They
+	// replace the preferences loaded from Source for the same team. If nil,
+	// the submission page is disabled.
+	Store PreferenceStore
+	// Tokens maps each team to the secret it must present to submit its
+	// preferences. If empty, submissions are refused unless OpenSubmissions
+	// is set.
+	Tokens map[string]string
+	// OpenSubmissions lets anyone submit for any team when Tokens is empty.
+	OpenSubmissions bool
+	// Options are the base options of every round the server sets up or
+	// resolves. Round, Seed and Overrides come from each request, which may
+	// also add to the constraints.
+	Options ladder.Options
+	// MaxPicks is the number of picks offered on the submission page. If
+	// zero, ladder.DefaultMaxPicks are offered.
+	MaxPicks int
+}
+
+// NewHandler returns the API handler, with the dashboard served at /. source
+// may be nil if every request carries its own teams and preferences.
+func NewHandler(source Source) http.Handler {
+	return New(Config{Source: source})
+}
+
+// New returns the API handler for config, with the dashboard served at / and
+// the submission page at /submit.
+func New(config Config) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/resolve", func(w http.ResponseWriter, r *http.Request) {
+		handleResolve(w, r, config)
+	})
+	mux.HandleFunc("/api/round", func(w http.ResponseWriter, r *http.Request) {
+		handleRound(w, r, config)
+	})
+	mux.HandleFunc("/api/eligible", func(w http.ResponseWriter, r *http.Request) {
+		handleEligible(w, r, config)
+	})
+	mux.HandleFunc("/api/demand", func(w http.ResponseWriter, r *http.Request) {
+		handleDemand(w, r, config)
+	})
+	mux.HandleFunc("/submit", func(w http.ResponseWriter, r *http.Request) {
+		handleSubmit(w, r, config)
+	})
+	mux.Handle("/", dashboardHandler())
+	return mux
+}
+
+// LoadRound loads the inputs of a round from the source, replacing the
+// preferences of every team that submitted on the submission page.
+func (config Config) LoadRound(season string, round int) ([]ladder.Team, []ladder.RawPreference, error) {
+	if config.Source == nil {
+		return nil, nil, ladder.Errorf(ladder.ErrValidation, "load round", "no source configured")
+	}
+	teams, prefs, err := config.Source(season, round)
+	if err != nil || config.Store == nil {
+		return teams, prefs, err
+	}
+	stored, err := config.Store.Preferences(season, round)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The previous opponent comes from the ladder, not from the team.
+	index := make(map[string]int)
+	for i, pref := range prefs {
+		index[pref.Team] = i
+	}
+	for _, pref := range stored {
+		i, ok := index[pref.Team]
+		if !ok {
+			prefs = append(prefs, pref.Raw())
+			continue
+		}
+		pref.PrevChallenged = prefs[i].PrevChallenged
+		prefs[i] = pref.Raw()
+	}
+	return teams, prefs, nil
+}
+
+// options returns the base options for the given round.
+func (config Config) options(round int) ladder.Options {
+	opts := config.Options
+	opts.Round = round
+	opts.Seed = 0
+	opts.Overrides = nil
+	opts.ManualAssign = nil
+	opts.ManualPicks = nil
+	opts.Log = nil
+	return opts
+}
+
+// resolveOptions merges a request into the base options. The request's
+// constraints are added to those of the server, and its other settings
+// replace the server's when given.
+func (config Config) resolveOptions(req ResolveRequest) ladder.Options {
+	opts := config.options(req.Round)
+	opts.Seed = req.Seed
+	opts.Overrides = req.Overrides
+	opts.Forbidden = append(append([]ladder.ForbiddenPair(nil), opts.Forbidden...), req.Forbidden...)
+	opts.AvoidSharedPlayers = opts.AvoidSharedPlayers || req.AvoidSharedPlayers
+	opts.Cooldowns = append([]ladder.CooldownRule(nil), opts.Cooldowns...)
+	for _, cooldown := range req.Cooldowns {
+		opts.Cooldowns = append(opts.Cooldowns, cooldown)
+	}
+	if !req.Deadline.IsZero() {
+		opts.Deadline = req.Deadline
+	}
+	if req.LatePolicy != nil {
+		opts.LatePolicy = *req.LatePolicy
+	}
+	if req.DefaultPreference != nil {
+		opts.DefaultPreference = req.DefaultPreference
+	}
+	if req.History != nil {
+		opts.History = req.History
+	}
+	return opts
+}
+
+func handleResolve(w http.ResponseWriter, r *http.Request, config Config) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	var req ResolveRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, ladder.WrapError(ladder.ErrParse, "decode request", err))
+		return
+	}
+
+	teams, prefs := req.Teams, req.Prefs
+	if len(teams) == 0 {
+		var err error
+		if teams, prefs, err = config.LoadRound(req.Season, req.Round); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+	}
+
+	result, err := ladder.Resolve(teams, prefs, config.resolveOptions(req))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newResolveResponse(result))
+}
+
+func newResolveResponse(result ladder.Result) ResolveResponse {
+	round := result.Round
+	resp := ResolveResponse{
+		Round:      round.Current,
+		Matches:    []Match{},
+		Unmatched:  []Unmatched{},
+		Rejections: result.Rejections,
+		Conflicts:  []Conflict{},
+		Late:       []ladder.LateEntry{},
+		Defaulted:  []string{},
+		Inactivity: round.Inactivity(),
+	}
+	for _, challenge := range result.Challenges {
+		resp.Matches = append(resp.Matches, Match{
+			Code:           fmt.Sprintf("%d-%02d", challenge.Round, challenge.MatchCode),
+			MatchCode:      challenge.MatchCode,
+			Challenger:     challenge.Challenger,
+			ChallengerRank: challenge.ChallengerRank,
+			ChallengerNew:  round.Teams[challenge.Challenger].New,
+			Defender:       challenge.Defender,
+			DefenderRank:   challenge.DefenderRank,
+			Pinned:         challenge.Pinned,
+		})
+	}
+	for _, challenger := range round.AscOrder {
+		pref := round.Prefs[challenger]
+		if challenger == "" || pref == nil || !pref.Challenge || round.Chals[challenger] != nil {
+			continue
+		}
+		resp.Unmatched = append(resp.Unmatched, Unmatched{
+			Challenger: challenger,
+			Rank:       round.Teams[challenger].Rank,
+			Rejections: round.Rejections[challenger],
+			Available:  round.Available(challenger),
+		})
+	}
+	resp.Late = append(resp.Late, result.Late...)
+	resp.Defaulted = append(resp.Defaulted, result.Defaulted...)
+	for _, conflict := range result.Conflicts {
+		resp.Conflicts = append(resp.Conflicts, Conflict(conflict))
+	}
+	return resp
+}
+
+// statusFor maps an error's kind to an HTTP status.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ladder.ErrParse):
+		return http.StatusBadRequest
+	case errors.Is(err, ladder.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ladder.ErrAuth), errors.Is(err, ladder.ErrNetwork):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+func errorKind(err error) string {
+	kinds := []struct {
+		kind error
+		name string
+	}{
+		{ladder.ErrAuth, "auth"},
+		{ladder.ErrNetwork, "network"},
+		{ladder.ErrParse, "parse"},
+		{ladder.ErrValidation, "validation"},
+	}
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			return k.name
+		}
+	}
+	return ""
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error(), Kind: errorKind(err)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/knagayama/ladder"
+)
+
+func TestResolve(t *testing.T) {
+	teams := []ladder.Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 2, Name: "B", Division: "X"},
+	}
+	prefs := []ladder.RawPreference{
+		{Team: "A", Accept: ladder.AnswerAccept, Challenge: ladder.AnswerNoChallenge},
+		{Team: "B", Accept: ladder.AnswerAccept, Challenge: ladder.AnswerChallenge, Picks: []string{"A"}},
+	}
+	body := func(req ResolveRequest) string {
+		b, err := json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	source := func(season string, round int) ([]ladder.Team, []ladder.RawPreference, error) {
+		return teams, prefs, nil
+	}
+	forbidden := []ladder.ForbiddenPair{{Team: "A", Opponent: "B", Reason: "same club"}}
+
+	tests := []struct {
+		name    string
+		config  Config
+		method  string
+		body    string
+		status  int
+		kind    string
+		matches int
+	}{
+		{"success", Config{}, http.MethodPost, body(ResolveRequest{Round: 1, Teams: teams, Prefs: prefs}), http.StatusOK, "", 1},
+		{"from the source", Config{Source: source}, http.MethodPost, `{"round": 1}`, http.StatusOK, "", 1},
+		{"base options", Config{Source: source, Options: ladder.Options{Forbidden: forbidden}}, http.MethodPost, `{"round": 1}`, http.StatusOK, "", 0},
+		{"request constraints", Config{}, http.MethodPost, body(ResolveRequest{Round: 1, Teams: teams, Prefs: prefs, Forbidden: forbidden}), http.StatusOK, "", 0},
+		{"unknown field", Config{}, http.MethodPost, `{"round": 1, "bogus": true}`, http.StatusBadRequest, "parse", 0},
+		{"malformed", Config{}, http.MethodPost, `{"round": `, http.StatusBadRequest, "parse", 0},
+		{"validation error", Config{}, http.MethodPost, body(ResolveRequest{Round: 1, Teams: append(teams, teams[0]), Prefs: prefs}), http.StatusUnprocessableEntity, "validation", 0},
+		{"get", Config{}, http.MethodGet, "", http.StatusMethodNotAllowed, "", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, "/resolve", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+			New(test.config).ServeHTTP(w, req)
+
+			if w.Code != test.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+			if test.status != http.StatusOK {
+				var resp errorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Error == "" || resp.Kind != test.kind {
+					t.Errorf("error %+v, want kind %q", resp, test.kind)
+				}
+				return
+			}
+			var resp ResolveResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Matches) != test.matches {
+				t.Errorf("%d matches, want %d: %+v", len(resp.Matches), test.matches, resp.Matches)
+			}
+		})
+	}
+}
+
+func TestResolvePageOnlyPreferenceKeepsPrevOpponent(t *testing.T) {
+	teams := []ladder.Team{
+		{Rank: 1, Name: "A", Division: "X"},
+		{Rank: 2, Name: "B", Division: "X"},
+		{Rank: 3, Name: "C", Division: "X"},
+	}
+	prefs := []ladder.RawPreference{
+		{Team: "A", Accept: ladder.AnswerAccept, Challenge: ladder.AnswerNoChallenge},
+		{Team: "B", Accept: ladder.AnswerAccept, Challenge: ladder.AnswerNoChallenge},
+	}
+	store := NewMemoryStore()
+	// C submitted on the page only, so the page did not know its previous
+	// opponent.
+	if err := store.SavePreference("", 2, ladder.ProcessedPreference{Team: "C", Challenge: true, Picks: []string{"B", "A"}}); err != nil {
+		t.Fatal(err)
+	}
+	config := Config{
+		Source: func(season string, round int) ([]ladder.Team, []ladder.RawPreference, error) {
+			return teams, prefs, nil
+		},
+		Store:   store,
+		Options: ladder.Options{PrevOpponents: map[string]string{"C": "B"}},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/resolve", strings.NewReader(`{"round": 2}`))
+	w := httptest.NewRecorder()
+	New(config).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var resp ResolveResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Matches) != 1 || resp.Matches[0].Challenger != "C" || resp.Matches[0].Defender != "A" {
+		t.Errorf("matches %+v, want C vs A since C challenged B last round", resp.Matches)
+	}
+}
@@ -0,0 +1,161 @@
+// Dashboard for the ladder API. The loaded inputs and the overrides applied
+// so far are kept here and posted to /resolve on every change.
+"use strict";
+
+const state = { season: "", round: 1, seed: 0, teams: null, prefs: null, overrides: [] };
+
+const $ = (selector) => document.querySelector(selector);
+
+function el(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined) node.textContent = text;
+  if (className) node.className = className;
+  return node;
+}
+
+function status(message, isError) {
+  $("#status").textContent = message;
+  $("#status").className = isError ? "error" : "";
+}
+
+async function request(url, options) {
+  const resp = await fetch(url, options);
+  const body = await resp.json();
+  if (!resp.ok) throw new Error(body.error || resp.statusText);
+  return body;
+}
+
+function rankLabel(rank, isNew) {
+  return isNew ? "New" : String(rank).padStart(2, "0") + "位";
+}
+
+function renderLadder(entries) {
+  const tbody = $("#ladder tbody");
+  tbody.replaceChildren();
+  for (const entry of entries) {
+    const row = el("tr");
+    if (!entry.submitted) row.className = "missing";
+    else if (!entry.accept) row.className = "declined";
+    row.append(
+      el("td", rankLabel(entry.rank, entry.new)),
+      el("td", entry.team),
+      el("td", entry.division),
+      el("td", entry.submitted ? (entry.accept ? "○" : "×") : "未提出"),
+      el("td", entry.submitted && entry.challenge ? "○" : ""),
+      el("td", (entry.picks || []).join(" > ")),
+      el("td", entry.last_resort || ""),
+    );
+    tbody.append(row);
+  }
+}
+
+function renderResult(result) {
+  const tbody = $("#matches tbody");
+  tbody.replaceChildren();
+  for (const match of result.matches) {
+    const row = el("tr", undefined, match.pinned ? "pinned" : "");
+    const remove = el("button", "Remove");
+    remove.onclick = () => override(`remove "${match.challenger}"`);
+    const action = el("td");
+    action.append(remove);
+    row.append(
+      el("td", `[${match.code}]`),
+      el("td", `${rankLabel(match.challenger_rank, match.challenger_new)} ${match.challenger}`),
+      el("td", `${rankLabel(match.defender_rank, false)} ${match.defender}`),
+      action,
+    );
+    tbody.append(row);
+  }
+
+  const unmatched = $("#unmatched");
+  unmatched.replaceChildren();
+  for (const team of result.unmatched) {
+    const block = el("div", undefined, "unmatched");
+    block.append(el("strong", `${team.rank}位 ${team.challenger}`));
+    const reasons = el("ul");
+    for (const rejection of team.rejections || []) {
+      reasons.append(el("li", `${rejection.defender}: ${rejection.reason}`));
+    }
+    block.append(reasons);
+    if (team.available && team.available.length > 0) {
+      const select = el("select");
+      for (const name of team.available) select.append(new Option(name, name));
+      const assign = el("button", "Assign");
+      assign.onclick = () => override(`assign "${team.challenger}" "${select.value}"`);
+      block.append(select, assign);
+    } else {
+      block.append(el("span", "No team available."));
+    }
+    unmatched.append(block);
+  }
+
+  const conflicts = $("#conflicts");
+  conflicts.replaceChildren();
+  for (const conflict of result.conflicts) {
+    const players = conflict.players.map((p) => p.id || p.friend_code).join(", ");
+    const where = conflict.same_match ? "against each other" : "in separate matches";
+    conflicts.append(el("li", `${conflict.team} / ${conflict.other} (${where}): ${players}`));
+  }
+
+  const overrides = $("#overrides");
+  overrides.replaceChildren();
+  for (const line of state.overrides) overrides.append(el("li", line));
+  $("#undo").disabled = state.overrides.length === 0;
+}
+
+async function resolve() {
+  status("Resolving…");
+  try {
+    const result = await request("/resolve", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({
+        season: state.season,
+        round: state.round,
+        seed: state.seed,
+        teams: state.teams,
+        prefs: state.prefs,
+        overrides: state.overrides,
+      }),
+    });
+    renderResult(result);
+    status(`${result.matches.length} matches, ${result.unmatched.length} unmatched.`);
+    return true;
+  } catch (err) {
+    status(err.message, true);
+    return false;
+  }
+}
+
+async function override(line) {
+  state.overrides.push(line);
+  if (!(await resolve())) state.overrides.pop();
+}
+
+$("#load").onsubmit = async (event) => {
+  event.preventDefault();
+  const form = new FormData(event.target);
+  state.season = form.get("season");
+  state.round = Number(form.get("round"));
+  state.seed = Number(form.get("seed"));
+  state.overrides = [];
+  status("Loading…");
+  try {
+    const params = new URLSearchParams({ season: state.season, round: state.round });
+    const round = await request(`/api/round?${params}`);
+    state.teams = round.teams;
+    state.prefs = round.prefs;
+    renderLadder(round.ladder);
+    $("#resolve").disabled = false;
+    status(`Loaded ${round.ladder.length} teams.`);
+  } catch (err) {
+    status(err.message, true);
+  }
+};
+
+$("#resolve").onclick = resolve;
+
+$("#undo").onclick = () => {
+  state.overrides.pop();
+  resolve();
+};
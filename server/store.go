@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/knagayama/ladder"
+)
+
+// A PreferenceStore keeps the preferences submitted through the submission
+// page. Implementations must be safe for concurrent use.
+type PreferenceStore interface {
+	Preferences(season string, round int) ([]ladder.ProcessedPreference, error)
+	SavePreference(season string, round int, pref ladder.ProcessedPreference) error
+}
+
+type roundKey struct {
+	season string
+	round  int
+}
+
+// MemoryStore is a PreferenceStore that forgets everything on restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	prefs map[roundKey]map[string]ladder.ProcessedPreference
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{prefs: make(map[roundKey]map[string]ladder.ProcessedPreference)}
+}
+
+func (s *MemoryStore) Preferences(season string, round int) ([]ladder.ProcessedPreference, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedPreferences(s.prefs[roundKey{season, round}]), nil
+}
+
+func (s *MemoryStore) SavePreference(season string, round int, pref ladder.ProcessedPreference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := roundKey{season, round}
+	if s.prefs[key] == nil {
+		s.prefs[key] = make(map[string]ladder.ProcessedPreference)
+	}
+	s.prefs[key][pref.Team] = pref
+	return nil
+}
+
+// FileStore is a PreferenceStore keeping one JSON file per round in Dir.
+type FileStore struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create preferences directory: %w", err)
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) path(season string, round int) string {
+	name := fmt.Sprintf("round-%d.json", round)
+	if season != "" {
+		name = fmt.Sprintf("%s-round-%d.json", filepath.Base(season), round)
+	}
+	return filepath.Join(s.Dir, name)
+}
+
+func (s *FileStore) load(season string, round int) (map[string]ladder.ProcessedPreference, error) {
+	prefs := make(map[string]ladder.ProcessedPreference)
+	b, err := ioutil.ReadFile(s.path(season, round))
+	if os.IsNotExist(err) {
+		return prefs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read preferences: %w", err)
+	}
+	if err := json.Unmarshal(b, &prefs); err != nil {
+		return nil, ladder.WrapError(ladder.ErrParse, "parse stored preferences", err)
+	}
+	return prefs, nil
+}
+
+func (s *FileStore) Preferences(season string, round int) ([]ladder.ProcessedPreference, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs, err := s.load(season, round)
+	if err != nil {
+		return nil, err
+	}
+	return sortedPreferences(prefs), nil
+}
+
+func (s *FileStore) SavePreference(season string, round int, pref ladder.ProcessedPreference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs, err := s.load(season, round)
+	if err != nil {
+		return err
+	}
+	prefs[pref.Team] = pref
+	b, err := json.MarshalIndent(prefs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode preferences: %w", err)
+	}
+	// Write to a temporary file first so that a crash never leaves a
+	// truncated file behind.
+	tmp := s.path(season, round) + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("unable to save preferences: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(season, round)); err != nil {
+		return fmt.Errorf("unable to save preferences: %w", err)
+	}
+	return nil
+}
+
+func sortedPreferences(prefs map[string]ladder.ProcessedPreference) []ladder.ProcessedPreference {
+	var sorted []ladder.ProcessedPreference
+	for _, pref := range prefs {
+		sorted = append(sorted, pref)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Team < sorted[j].Team })
+	return sorted
+}
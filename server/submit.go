@@ -0,0 +1,195 @@
+package server
+
+import (
+	"crypto/subtle"
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/knagayama/ladder"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var submitTemplate = template.Must(template.ParseFS(templates, "templates/submit.html"))
+
+// A lastResortOption is a choice of the last-resort radio.
+type lastResortOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+// A pickField is one of the dropdowns of preferred opponents.
+type pickField struct {
+	Label    string
+	Selected string
+}
+
+type submitPage struct {
+	Season        string
+	Round         int
+	Team          string
+	Rank          int
+	Token         string
+	Pref          ladder.ProcessedPreference
+	Challengeable []string
+	Picks         []pickField
+	LastResorts   []lastResortOption
+	Saved         bool
+	Error         string
+}
+
+// handleSubmit serves the preference form of a single team. The choices offered
+// are computed from the ladder, so a saved preference never names a team the
+// challenger is not allowed to challenge.
+func handleSubmit(w http.ResponseWriter, r *http.Request, config Config) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "use GET or POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if config.Store == nil {
+		http.Error(w, "submissions are disabled", http.StatusNotFound)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page := submitPage{
+		Season: r.FormValue("season"),
+		Team:   r.FormValue("team"),
+		Token:  r.FormValue("token"),
+	}
+	var err error
+	if page.Round, err = parseRound(r.FormValue("round")); err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+	if !config.authorized(page.Team, page.Token) {
+		http.Error(w, "invalid team or token", http.StatusForbidden)
+		return
+	}
+
+	teams, prefs, err := config.LoadRound(page.Season, page.Round)
+	if err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+	round, err := ladder.NewRound(teams, prefs, config.options(page.Round))
+	if err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+	team := round.Teams[page.Team]
+	if team == nil {
+		http.Error(w, fmt.Sprintf("unknown team %q", page.Team), http.StatusNotFound)
+		return
+	}
+	page.Rank = team.Rank
+	page.Challengeable = round.Challengeable(page.Team)
+	if pref := round.Prefs[page.Team]; pref != nil {
+		page.Pref = *pref
+	} else {
+		page.Pref = ladder.ProcessedPreference{Team: page.Team, Accept: true}
+	}
+
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		pref, err := parseSubmission(r, page.Team, page.Challengeable, config.maxPicks())
+		if err == nil {
+			pref.PrevChallenged = page.Pref.PrevChallenged
+			now := time.Now()
+			pref.SubmittedAt = &now
+			err = config.Store.SavePreference(page.Season, page.Round, pref)
+		}
+		if err != nil {
+			page.Error = err.Error()
+			status = statusFor(err)
+		} else {
+			page.Saved = true
+		}
+		page.Pref = pref
+	}
+
+	for i := 0; i < config.maxPicks(); i++ {
+		field := pickField{Label: fmt.Sprintf("第%d希望", i+1)}
+		if i < len(page.Pref.Picks) {
+			field.Selected = page.Pref.Picks[i]
+		}
+		page.Picks = append(page.Picks, field)
+	}
+	for _, l := range []ladder.LastResortChallenge{ladder.None, ladder.MinRank, ladder.MaxRank, ladder.Any} {
+		page.LastResorts = append(page.LastResorts, lastResortOption{
+			Value:    l.String(),
+			Label:    l.Answer(),
+			Selected: l == page.Pref.LastResortPref,
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	submitTemplate.Execute(w, page)
+}
+
+func (config Config) maxPicks() int {
+	if config.MaxPicks > 0 {
+		return config.MaxPicks
+	}
+	return ladder.DefaultMaxPicks
+}
+
+func (config Config) authorized(team string, token string) bool {
+	if len(config.Tokens) == 0 {
+		return config.OpenSubmissions
+	}
+	want, ok := config.Tokens[team]
+	return ok && subtle.ConstantTimeCompare([]byte(want), []byte(token)) == 1
+}
+
+// parseSubmission reads a submitted form, rejecting more than maxPicks picks,
+// picks outside challengeable and picks naming the same team twice.
+func parseSubmission(r *http.Request, team string, challengeable []string, maxPicks int) (ladder.ProcessedPreference, error) {
+	pref := ladder.ProcessedPreference{
+		Team:      team,
+		Accept:    r.PostFormValue("accept") != "",
+		Challenge: r.PostFormValue("challenge") != "",
+	}
+	const op = "submit preferences"
+	for _, pick := range r.PostForm["pick"] {
+		if pick != "" {
+			pref.Picks = append(pref.Picks, pick)
+		}
+	}
+	if len(pref.Picks) > maxPicks {
+		return pref, ladder.Errorf(ladder.ErrValidation, op, "at most %d picks are allowed", maxPicks)
+	}
+
+	lastResort, err := ladder.ParseLastResort(r.PostFormValue("last_resort"))
+	if err != nil {
+		return pref, err
+	}
+	pref.LastResortPref = lastResort
+
+	allowed := make(map[string]bool)
+	for _, name := range challengeable {
+		allowed[name] = true
+	}
+	picked := make(map[string]bool)
+	for _, pick := range pref.Picks {
+		if !allowed[pick] {
+			return pref, ladder.Errorf(ladder.ErrValidation, op, "%s cannot challenge %s", team, pick)
+		}
+		if picked[pick] {
+			return pref, ladder.Errorf(ladder.ErrValidation, op, "%s is picked more than once", pick)
+		}
+		picked[pick] = true
+	}
+	return pref, nil
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/knagayama/ladder"
+)
+
+func TestSubmitAuthorization(t *testing.T) {
+	source := func(season string, round int) ([]ladder.Team, []ladder.RawPreference, error) {
+		return []ladder.Team{
+			{Rank: 1, Name: "A", Division: "X"},
+			{Rank: 2, Name: "B", Division: "X"},
+		}, nil, nil
+	}
+	tokens := map[string]string{"A": "a-secret", "B": "b-secret"}
+
+	tests := []struct {
+		name   string
+		config Config
+		query  string
+		status int
+	}{
+		{"no tokens", Config{}, "team=B&round=1", http.StatusForbidden},
+		{"open submissions", Config{OpenSubmissions: true}, "team=B&round=1", http.StatusOK},
+		{"no token", Config{Tokens: tokens}, "team=B&round=1", http.StatusForbidden},
+		{"token of another team", Config{Tokens: tokens}, "team=B&round=1&token=a-secret", http.StatusForbidden},
+		{"token", Config{Tokens: tokens}, "team=B&round=1&token=b-secret", http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			config.Source = source
+			config.Store = NewMemoryStore()
+			w := httptest.NewRecorder()
+			New(config).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/submit?"+test.query, nil))
+			if w.Code != test.status {
+				t.Errorf("status %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+		})
+	}
+}
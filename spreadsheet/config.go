@@ -0,0 +1,117 @@
+package spreadsheet
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/knagayama/ladder"
+)
+
+// Environment variables overriding the profile and the credential paths.
+const (
+	EnvProfile     = "LADDER_PROFILE"
+	EnvCredentials = "LADDER_CREDENTIALS"
+	EnvToken       = "LADDER_TOKEN"
+)
+
+// DefaultProfile is used when no profile is given.
+const DefaultProfile = "default"
+
+// Config locates the credentials of one Google account.
+type Config struct {
+	// CredentialsFile is an OAuth client secret or a service account key.
+	CredentialsFile string
+	// TokenFile caches the token of an OAuth client after signing in.
+	TokenFile string
+}
+
+// ProfileConfig returns the paths of a named profile, e.g. one per league
+// account, under the user config dir: ladder/<profile>/credentials.json and
+// token.json. An empty profile reads $LADDER_PROFILE, then falls back to
+// DefaultProfile. $LADDER_CREDENTIALS and $LADDER_TOKEN override the paths.
+//
+// For compatibility with earlier versions, the default profile uses the
+// files in the working directory if it has no credentials of its own.
+func ProfileConfig(profile string) (Config, error) {
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	if strings.ContainsAny(profile, `/\`) || profile == "." || profile == ".." {
+		return Config{}, ladder.Errorf(ladder.ErrValidation, "select profile", "invalid profile name %q", profile)
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return Config{}, ladder.WrapError(ladder.ErrAuth, "find config dir", err)
+	}
+	dir = filepath.Join(dir, "ladder", profile)
+	config := Config{
+		CredentialsFile: filepath.Join(dir, "credentials.json"),
+		TokenFile:       filepath.Join(dir, "token.json"),
+	}
+	if profile == DefaultProfile {
+		if _, err := os.Stat(config.CredentialsFile); os.IsNotExist(err) {
+			if _, err := os.Stat("credentials.json"); err == nil {
+				config = Config{CredentialsFile: "credentials.json", TokenFile: "token.json"}
+			}
+		}
+	}
+
+	if path := os.Getenv(EnvCredentials); path != "" {
+		config.CredentialsFile = path
+	}
+	if path := os.Getenv(EnvToken); path != "" {
+		config.TokenFile = path
+	}
+	return config, nil
+}
+
+// revokeURL is Google's OAuth 2.0 token revocation endpoint.
+const revokeURL = "https://oauth2.googleapis.com/revoke"
+
+// Logout revokes the cached token of config and deletes its file. The file is
+// deleted even if Google could not be reached, in which case the error is
+// returned after deleting it.
+func Logout(config Config) error {
+	tok, err := tokenFromFile(config.TokenFile)
+	if os.IsNotExist(err) {
+		return ladder.Errorf(ladder.ErrValidation, "log out", "no token at %s", config.TokenFile)
+	}
+
+	var revokeErr error
+	if err != nil {
+		revokeErr = ladder.WrapError(ladder.ErrParse, "read token", err)
+	} else {
+		// Revoking the refresh token also revokes its access tokens.
+		token := tok.RefreshToken
+		if token == "" {
+			token = tok.AccessToken
+		}
+		revokeErr = revoke(token)
+	}
+
+	if err := os.Remove(config.TokenFile); err != nil {
+		return ladder.WrapError(ladder.ErrAuth, "delete token", err)
+	}
+	return revokeErr
+}
+
+func revoke(token string) error {
+	resp, err := http.PostForm(revokeURL, url.Values{"token": {token}})
+	if err != nil {
+		return ladder.WrapError(ladder.ErrNetwork, "revoke token", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ladder.Errorf(ladder.ErrAuth, "revoke token", "%s", resp.Status)
+	}
+	fmt.Println("Revoked the token.")
+	return nil
+}
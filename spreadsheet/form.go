@@ -0,0 +1,271 @@
+package spreadsheet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/knagayama/ladder"
+)
+
+// A Form describes the responses tab of the Google Form the teams submit
+// their preferences with.
+type Form struct {
+	// Sheet is the name of the responses tab.
+	Sheet string `json:"sheet"`
+	// A team's responses after the deadline only count if it has none
+	// before. A zero deadline takes the latest response of every team.
+	Deadline time.Time `json:"deadline"`
+	// TimeZone is the IANA time zone of the spreadsheet, which its
+	// timestamps are in. If empty, the local time zone is used.
+	TimeZone string      `json:"timezone"`
+	Columns  FormColumns `json:"columns"`
+	// Log, if set, receives which response of every team is used.
+	Log io.Writer `json:"-"`
+}
+
+// FormColumns are the column letters of the answers in the responses tab.
+type FormColumns struct {
+	Timestamp string `json:"timestamp"`
+	Email     string `json:"email"`
+	Team      string `json:"team"`
+	Accept    string `json:"accept"`
+	Challenge string `json:"challenge"`
+	// Picks are the columns of the picks, most wanted first. If there is
+	// only one, it may hold several comma separated picks.
+	Picks      []string `json:"picks"`
+	LastResort string   `json:"last_resort"`
+}
+
+// UnmarshalJSON also reads forms described before picks were a list, with
+// the columns first, second and third. Columns missing from the JSON keep
+// their current value, e.g. that of DefaultForm.
+func (columns *FormColumns) UnmarshalJSON(b []byte) error {
+	type formColumns FormColumns
+	var v struct {
+		formColumns
+		First  string `json:"first"`
+		Second string `json:"second"`
+		Third  string `json:"third"`
+	}
+	v.formColumns = formColumns(*columns)
+	defaults := v.Picks
+	v.Picks = nil
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*columns = FormColumns(v.formColumns)
+	if columns.Picks != nil {
+		return nil
+	}
+	columns.Picks = defaults
+	if v.First == "" && v.Second == "" && v.Third == "" {
+		return nil
+	}
+	columns.Picks = nil
+	for i, letter := range []string{v.First, v.Second, v.Third} {
+		if letter == "" && i < len(defaults) {
+			letter = defaults[i]
+		}
+		if letter != "" {
+			columns.Picks = append(columns.Picks, letter)
+		}
+	}
+	return nil
+}
+
+// DefaultForm is the responses tab as Google Forms creates it, with the
+// questions in the order of the challenge form.
+func DefaultForm() Form {
+	return Form{
+		Sheet: "Form Responses 1",
+		Columns: FormColumns{
+			Timestamp:  "A",
+			Email:      "B",
+			Team:       "C",
+			Accept:     "D",
+			Challenge:  "E",
+			Picks:      []string{"F", "G", "H"},
+			LastResort: "I",
+		},
+	}
+}
+
+func (form Form) rangeName() string {
+	return fmt.Sprintf("'%s'!A2:ZZ", strings.ReplaceAll(form.Sheet, "'", "''"))
+}
+
+// FetchForm reads the teams and the raw form responses with a single request.
+func (c *Client) FetchForm(form Form) (Rows, error) {
+	values, err := c.BatchGet(teamsRange, form.rangeName())
+	if err != nil {
+		return Rows{}, err
+	}
+	return Rows{Teams: values[0], Form: values[1]}, nil
+}
+
+// ParseForm converts fetched rows into teams and preferences, taking each
+// team's latest response before the deadline, or its latest late response if
+// it has none, for the round's late policy to handle. Previous opponents are
+// not asked in the form, so PrevChallenged is left empty for the caller to
+// fill in.
+func (rows Rows) ParseForm(form Form) ([]ladder.Team, []ladder.RawPreference, error) {
+	teams, err := parseTeams(rows.Teams)
+	if err != nil {
+		return nil, nil, err
+	}
+	responses, err := parseResponses(rows.Form, form)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	known := make(map[string]bool)
+	for _, team := range teams {
+		known[team.Name] = true
+	}
+	latest := make(map[string]FormResponse)
+	for _, response := range responses {
+		team := response.Pref.Team
+		if !known[team] {
+			form.trace("Ignoring the response of unknown team", team, "by", response.Email)
+			continue
+		}
+		if previous, ok := latest[team]; ok && !form.newer(response, previous) {
+			continue
+		}
+		if len(form.Columns.Picks) == 1 && len(response.Pref.Picks) == 1 {
+			response.Pref.Picks = ladder.ParsePicks(response.Pref.Picks[0], func(name string) bool { return known[name] })
+		}
+		latest[team] = response
+	}
+
+	var names []string
+	for team := range latest {
+		names = append(names, team)
+	}
+	sort.Strings(names)
+	var prefs []ladder.RawPreference
+	for _, team := range names {
+		form.trace("Using the response of", team, "by", latest[team].Email, "at", latest[team].Time)
+		prefs = append(prefs, latest[team].Pref)
+	}
+	return teams, prefs, nil
+}
+
+func (form Form) trace(a ...interface{}) {
+	if form.Log != nil {
+		fmt.Fprintln(form.Log, a...)
+	}
+}
+
+// newer reports whether response replaces previous: punctual responses beat
+// late ones, and otherwise the latest wins.
+func (form Form) newer(response FormResponse, previous FormResponse) bool {
+	if !form.Deadline.IsZero() {
+		late, previousLate := response.Time.After(form.Deadline), previous.Time.After(form.Deadline)
+		if late != previousLate {
+			return previousLate
+		}
+	}
+	return response.Time.After(previous.Time)
+}
+
+// A FormResponse is a row of the responses tab.
+type FormResponse struct {
+	Time  time.Time
+	Email string
+	Pref  ladder.RawPreference
+}
+
+func parseResponses(rows [][]interface{}, form Form) ([]FormResponse, error) {
+	const op = "parse form responses"
+	location := time.Local
+	if form.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(form.TimeZone); err != nil {
+			return nil, ladder.WrapError(ladder.ErrParse, op, err)
+		}
+	}
+
+	columns := form.Columns
+	letters := []string{columns.Timestamp, columns.Email, columns.Team, columns.Accept, columns.Challenge,
+		columns.LastResort}
+	letters = append(letters, columns.Picks...)
+	index := make([]int, len(letters))
+	for i, letter := range letters {
+		var ok bool
+		if index[i], ok = columnIndex(letter); !ok {
+			return nil, ladder.Errorf(ladder.ErrParse, op, "invalid column %q", letter)
+		}
+	}
+
+	var responses []FormResponse
+	for i, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		when, ok := cellTime(row, index[0], location)
+		if !ok {
+			return nil, ladder.Errorf(ladder.ErrParse, op, "row %d has no valid timestamp", i+2)
+		}
+		var picks []string
+		for _, i := range index[6:] {
+			picks = append(picks, cellText(row, i))
+		}
+		response := FormResponse{
+			Time:  when,
+			Email: cellText(row, index[1]),
+			Pref: ladder.RawPreference{
+				Team:           strings.TrimSpace(cellText(row, index[2])),
+				Accept:         cellText(row, index[3]),
+				Challenge:      cellText(row, index[4]),
+				Picks:          ladder.TrimPicks(picks...),
+				LastResortPref: cellText(row, index[5]),
+				SubmittedAt:    &when,
+			},
+		}
+		if response.Pref.Team == "" {
+			return nil, ladder.Errorf(ladder.ErrParse, op, "row %d has no team", i+2)
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// columnIndex converts a column letter such as "A" or "AB" to an index.
+func columnIndex(letter string) (int, bool) {
+	letter = strings.ToUpper(strings.TrimSpace(letter))
+	if letter == "" || len(letter) > 2 {
+		return 0, false
+	}
+	index := 0
+	for _, c := range letter {
+		if c < 'A' || c > 'Z' {
+			return 0, false
+		}
+		index = index*26 + int(c-'A') + 1
+	}
+	return index - 1, true
+}
+
+// cellTime reads a timestamp, which unformatted values give as days since
+// 1899-12-30 in the spreadsheet's time zone.
+func cellTime(row []interface{}, i int, location *time.Location) (time.Time, bool) {
+	if i >= len(row) {
+		return time.Time{}, false
+	}
+	switch value := row[i].(type) {
+	case float64:
+		days := math.Floor(value)
+		seconds := math.Round((value - days) * 24 * 60 * 60)
+		return time.Date(1899, 12, 30+int(days), 0, 0, int(seconds), 0, location), true
+	case string:
+		when, err := time.ParseInLocation("2006/01/02 15:04:05", value, location)
+		return when, err == nil
+	}
+	return time.Time{}, false
+}
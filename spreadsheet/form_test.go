@@ -0,0 +1,86 @@
+package spreadsheet
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFormColumnsUnmarshalJSON(t *testing.T) {
+	defaults := DefaultForm().Columns
+	tests := []struct {
+		name string
+		json string
+		want func(c *FormColumns)
+	}{
+		{"empty", `{}`, func(c *FormColumns) {}},
+		{"partial", `{"columns":{"team":"J"}}`, func(c *FormColumns) {
+			c.Team = "J"
+		}},
+		{"picks", `{"columns":{"picks":["K","L","M","N"]}}`, func(c *FormColumns) {
+			c.Picks = []string{"K", "L", "M", "N"}
+		}},
+		{"legacy", `{"columns":{"first":"K","second":"L","third":"M"}}`, func(c *FormColumns) {
+			c.Picks = []string{"K", "L", "M"}
+		}},
+		{"partial legacy", `{"columns":{"second":"X","last_resort":"Z"}}`, func(c *FormColumns) {
+			c.Picks = []string{"F", "X", "H"}
+			c.LastResort = "Z"
+		}},
+		{"picks win over legacy", `{"columns":{"picks":["K"],"first":"L"}}`, func(c *FormColumns) {
+			c.Picks = []string{"K"}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			form := DefaultForm()
+			if err := json.Unmarshal([]byte(test.json), &form); err != nil {
+				t.Fatal(err)
+			}
+			want := defaults
+			want.Picks = append([]string(nil), defaults.Picks...)
+			test.want(&want)
+			if !reflect.DeepEqual(form.Columns, want) {
+				t.Errorf("got %+v, want %+v", form.Columns, want)
+			}
+		})
+	}
+}
+
+func TestParseFormPicks(t *testing.T) {
+	teams := [][]interface{}{
+		{1.0, 1.0, false, "A", "Smith, Jones"},
+		{2.0, 2.0, false, "A", "B"},
+		{3.0, 3.0, false, "A", "C"},
+	}
+	responses := [][]interface{}{
+		{"2024/01/01 10:00:00", "c@example.com", "C", "yes", "yes", "", "Smith, Jones、B"},
+		{"2024/01/01 10:00:00", "b@example.com", "B", "yes", "yes", "", "Smith, Jones"},
+	}
+	tests := []struct {
+		name  string
+		picks []string
+		want  map[string][]string
+	}{
+		{"one column", []string{"G"}, map[string][]string{"C": {"Smith, Jones", "B"}, "B": {"Smith, Jones"}}},
+		{"a column per pick", []string{"G", "H"}, map[string][]string{"C": {"Smith, Jones、B"}, "B": {"Smith, Jones"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			form := DefaultForm()
+			form.Columns.Picks = test.picks
+			form.Columns.LastResort = "F"
+			_, prefs, err := Rows{Teams: teams, Form: responses}.ParseForm(form)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string][]string)
+			for _, pref := range prefs {
+				got[pref.Team] = pref.Picks
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("picks = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
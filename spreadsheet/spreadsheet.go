@@ -0,0 +1,417 @@
+// Package spreadsheet loads teams and preferences from the challenge form
+// spreadsheet.
+package spreadsheet
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/knagayama/ladder"
+)
+
+// Retrieve a token, saves the token, then returns the generated client.
+func getClient(config *oauth2.Config, tokFile string) (*http.Client, error) {
+	// The token file stores the user's access and refresh tokens, and is
+	// created automatically when the authorization flow completes for the first
+	// time.
+	tok, err := tokenFromFile(tokFile)
+	if err != nil {
+		tok, err = getTokenFromWeb(config)
+		if err != nil {
+			return nil, err
+		}
+		if err := saveToken(tokFile, tok); err != nil {
+			return nil, err
+		}
+	}
+	return config.Client(context.Background(), tok), nil
+}
+
+// Request a token from the web, then returns the retrieved token. The browser
+// is redirected back to a listener on the loopback interface, and the code is
+// bound to this request with a random state and a PKCE verifier.
+func getTokenFromWeb(config *oauth2.Config) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrAuth, "listen for oauth redirect", err)
+	}
+	defer listener.Close()
+
+	state, err := randomState()
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrAuth, "generate oauth state", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+	redirect := *config
+	redirect.RedirectURL = fmt.Sprintf("http://%s/", listener.Addr())
+	authURL := redirect.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	fmt.Printf("Go to the following link in your browser to authorize access "+
+		"to the spreadsheet: \n%v\n", authURL)
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var res result
+		switch {
+		case query.Get("state") != state:
+			// Not our request; keep waiting for the real redirect.
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		case query.Get("error") != "":
+			res.err = fmt.Errorf("authorization denied: %s", query.Get("error"))
+		case query.Get("code") == "":
+			res.err = fmt.Errorf("no authorization code in redirect")
+		default:
+			res.code = query.Get("code")
+		}
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorized. You can close this window.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	var res result
+	select {
+	case res = <-results:
+	case <-time.After(authTimeout):
+		res.err = fmt.Errorf("no response after %v", authTimeout)
+	}
+	if res.err != nil {
+		return nil, ladder.WrapError(ladder.ErrAuth, "authorize in browser", res.err)
+	}
+
+	tok, err := redirect.Exchange(context.TODO(), res.code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrAuth, "retrieve token from web", err)
+	}
+	return tok, nil
+}
+
+// authTimeout bounds how long getTokenFromWeb waits for the browser.
+const authTimeout = 5 * time.Minute
+
+func randomState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Retrieves a token from a local file.
+func tokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tok := &oauth2.Token{}
+	err = json.NewDecoder(f).Decode(tok)
+	return tok, err
+}
+
+// Saves a token to a file path.
+func saveToken(path string, token *oauth2.Token) error {
+	fmt.Printf("Saving credential file to: %s\n", path)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return ladder.WrapError(ladder.ErrAuth, "cache oauth token", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return ladder.WrapError(ladder.ErrAuth, "cache oauth token", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(token); err != nil {
+		return ladder.WrapError(ladder.ErrAuth, "cache oauth token", err)
+	}
+	return nil
+}
+
+// The spreadsheet is only ever read.
+const scope = "https://www.googleapis.com/auth/spreadsheets.readonly"
+
+// getService authorizes with the credentials file. A service account key is
+// used as is, so the tool can run unattended; an OAuth client asks the user
+// to sign in once and caches the token in the token file.
+func getService(cfg Config) (*sheets.Service, error) {
+	b, err := ioutil.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrAuth, "read client secret file", err)
+	}
+
+	var client *http.Client
+	var key struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(b, &key) == nil && key.Type == "service_account" {
+		config, err := google.JWTConfigFromJSON(b, scope)
+		if err != nil {
+			return nil, ladder.WrapError(ladder.ErrAuth, "parse service account key", err)
+		}
+		client = config.Client(context.Background())
+	} else {
+		// If modifying these scopes, delete your previously saved token.
+		config, err := google.ConfigFromJSON(b, scope)
+		if err != nil {
+			return nil, ladder.WrapError(ladder.ErrAuth, "parse client secret file", err)
+		}
+		if client, err = getClient(config, cfg.TokenFile); err != nil {
+			return nil, err
+		}
+	}
+
+	srv, err := sheets.New(client)
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrNetwork, "create sheets client", err)
+	}
+	return srv, nil
+}
+
+// Cell helpers return false if the cell is missing or of the wrong type,
+// instead of panicking on malformed rows.
+func cellString(row []interface{}, i int) (string, bool) {
+	if i >= len(row) {
+		return "", false
+	}
+	value, ok := row[i].(string)
+	return value, ok
+}
+
+func cellInt(row []interface{}, i int) (int, bool) {
+	if i >= len(row) {
+		return 0, false
+	}
+	value, ok := row[i].(float64)
+	return int(value), ok
+}
+
+// cellText returns the cell as it reads in the sheet, e.g. "12" rather than
+// "12.0" for a team named with digits only.
+func cellText(row []interface{}, i int) string {
+	if i >= len(row) {
+		return ""
+	}
+	if value, ok := row[i].(float64); ok {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return fmt.Sprint(row[i])
+}
+
+func cellBool(row []interface{}, i int) (bool, bool) {
+	if i >= len(row) {
+		return false, false
+	}
+	value, ok := row[i].(bool)
+	return value, ok
+}
+
+// The challenge form spreadsheet and the ranges of its preformatted sheets.
+const (
+	spreadsheetId = "1zEw8Eb2WGzY8nZt_6B5rL9v_6PUW7CUBusvoqccrayQ"
+	teamsRange    = "teams!A2:G"
+	prefsRange    = "prefs!A2:ZZ"
+)
+
+// Quota and server errors are retried with exponential backoff.
+const maxAttempts = 5
+
+var retryDelay = time.Second
+
+// A Client reads the challenge form spreadsheet. It authorizes once and can
+// be reused for every load.
+type Client struct {
+	srv *sheets.Service
+}
+
+// NewClient authorizes with the credentials of config, asking the user to
+// sign in if needed.
+func NewClient(config Config) (*Client, error) {
+	srv, err := getService(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{srv: srv}, nil
+}
+
+// BatchGet reads the ranges in a single request, returning their rows in the
+// same order. Values are unformatted, so numbers are float64.
+func (c *Client) BatchGet(ranges ...string) ([][][]interface{}, error) {
+	var resp *sheets.BatchGetValuesResponse
+	var err error
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		resp, err = c.srv.Spreadsheets.Values.BatchGet(spreadsheetId).Ranges(ranges...).ValueRenderOption("UNFORMATTED_VALUE").Do()
+		if err == nil || attempt == maxAttempts || !retryable(err) {
+			break
+		}
+		// Add up to 50% jitter so that parallel runs do not retry in step.
+		wait := delay + time.Duration(mathrand.Int63n(int64(delay)/2+1))
+		fmt.Printf("Spreadsheet request failed (%v), retrying in %v\n", err, wait.Round(time.Millisecond))
+		time.Sleep(wait)
+		delay *= 2
+	}
+	if err != nil {
+		return nil, ladder.WrapError(ladder.ErrNetwork, "retrieve sheets", err)
+	}
+	if len(resp.ValueRanges) != len(ranges) {
+		return nil, ladder.Errorf(ladder.ErrNetwork, "retrieve sheets", "got %d ranges, want %d", len(resp.ValueRanges), len(ranges))
+	}
+
+	values := make([][][]interface{}, len(ranges))
+	for i, valueRange := range resp.ValueRanges {
+		values[i] = valueRange.Values
+	}
+	return values, nil
+}
+
+// retryable reports whether err is a rate limit or a transient server error.
+func retryable(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= 500
+}
+
+// Rows are the cells of the teams and prefs sheets as fetched, before
+// parsing, so that they can be archived and parsed again later.
+type Rows struct {
+	Teams [][]interface{} `json:"teams"`
+	Prefs [][]interface{} `json:"prefs,omitempty"`
+	// Form holds the raw form responses when they are read instead of the
+	// prefs sheet.
+	Form [][]interface{} `json:"form,omitempty"`
+}
+
+// Fetch reads the teams and the preferences with a single request.
+func (c *Client) Fetch() (Rows, error) {
+	values, err := c.BatchGet(teamsRange, prefsRange)
+	if err != nil {
+		return Rows{}, err
+	}
+	return Rows{Teams: values[0], Prefs: values[1]}, nil
+}
+
+// Parse converts fetched rows into teams and preferences.
+func (rows Rows) Parse() ([]ladder.Team, []ladder.RawPreference, error) {
+	teams, err := parseTeams(rows.Teams)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefs, err := parsePrefs(rows.Prefs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return teams, prefs, nil
+}
+
+// Load fetches and parses the teams and the preferences.
+func (c *Client) Load() ([]ladder.Team, []ladder.RawPreference, error) {
+	rows, err := c.Fetch()
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows.Parse()
+}
+
+func parseTeams(rows [][]interface{}) ([]ladder.Team, error) {
+	var teams []ladder.Team
+	for i, row := range rows {
+		// The spreadsheet is ordered as prev_rank, rank, new, division, team,
+		// followed by optional comma separated groups and force_accept.
+		var team ladder.Team
+		var ok [5]bool
+		team.PrevRank, ok[0] = cellInt(row, 0)
+		team.Rank, ok[1] = cellInt(row, 1)
+		team.New, ok[2] = cellBool(row, 2)
+		team.Division, ok[3] = cellString(row, 3)
+		team.Name, ok[4] = cellString(row, 4)
+		for column, valid := range ok {
+			if !valid {
+				return nil, ladder.Errorf(ladder.ErrParse, "parse teams sheet", "row %d, column %c is missing or malformed", i+2, 'A'+column)
+			}
+		}
+		if len(row) > 5 {
+			team.Groups = ladder.ParseGroups(cellText(row, 5))
+		}
+		if len(row) > 6 {
+			team.ForceAccept, _ = cellBool(row, 6)
+		}
+		team.MAC = ladder.DivisionMAC(team.Division, team.Rank)
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+// TeamRows formats teams as the rows of the teams sheet, in ladder order with
+// the new teams last, in the layout Rows.Parse reads.
+func TeamRows(teams []ladder.Team) [][]interface{} {
+	sorted := make([]ladder.Team, len(teams))
+	copy(sorted, teams)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].New != sorted[j].New {
+			return !sorted[i].New
+		}
+		return sorted[i].Rank < sorted[j].Rank
+	})
+	var rows [][]interface{}
+	for _, team := range sorted {
+		rows = append(rows, []interface{}{team.PrevRank, team.Rank, team.New, team.Division, team.Name, strings.Join(team.Groups, ", "), team.ForceAccept})
+	}
+	return rows
+}
+
+func parsePrefs(rows [][]interface{}) ([]ladder.RawPreference, error) {
+	var raw_prefs []ladder.RawPreference
+	for i, row := range rows {
+		// The spreadsheet is formatted as accept, challenge, current_rank, last_resort,
+		// prev_challenged, then one column per pick, and team last. Each pick
+		// column holds a single team.
+		if len(row) < 6 {
+			return nil, ladder.Errorf(ladder.ErrParse, "parse prefs sheet", "row %d has %d columns, want at least 6", i+2, len(row))
+		}
+		var pref ladder.RawPreference
+		pref.Accept = cellText(row, 0)
+		pref.Challenge = cellText(row, 1)
+		pref.LastResortPref = cellText(row, 3)
+		pref.PrevChallenged = cellText(row, 4)
+		var picks []string
+		for j := 5; j < len(row)-1; j++ {
+			picks = append(picks, cellText(row, j))
+		}
+		pref.Picks = ladder.TrimPicks(picks...)
+		pref.Team = cellText(row, len(row)-1)
+		raw_prefs = append(raw_prefs, pref)
+	}
+	return raw_prefs, nil
+}
@@ -0,0 +1,34 @@
+package spreadsheet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePrefsPicks(t *testing.T) {
+	rows := [][]interface{}{
+		{"yes", "yes", 3.0, "", "", "Smith, Jones", "A", "", "C"},
+		{"yes", "yes", 4.0, "", "", "A", "B", "Smith, Jones", "E", "D"},
+		{"yes", "no", 5.0, "", "", "E"},
+	}
+	prefs, err := parsePrefs(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"C": {"Smith, Jones", "A"},
+		"D": {"A", "B", "Smith, Jones", "E"},
+		"E": nil,
+	}
+	if len(prefs) != len(want) {
+		t.Fatalf("parsePrefs() = %+v, want %d preferences", prefs, len(want))
+	}
+	for _, pref := range prefs {
+		if picks, ok := want[pref.Team]; !ok || !reflect.DeepEqual(pref.Picks, picks) {
+			t.Errorf("picks of %q = %q, want %q", pref.Team, pref.Picks, picks)
+		}
+	}
+	if _, err := parsePrefs([][]interface{}{{"yes", "yes", 3.0, "", "A"}}); err == nil {
+		t.Error("parsePrefs() of a row without a team succeeded")
+	}
+}